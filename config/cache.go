@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache 缓存接口，值为序列化后的字节
+type Cache interface {
+	// Get 读取缓存，未命中或已过期时 ok 为 false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入缓存，ttl 为过期时间
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除缓存
+	Delete(ctx context.Context, key string) error
+}
+
+var AppCache Cache
+
+// ConnectCache 根据配置初始化缓存
+func ConnectCache() {
+	switch AppConfig.Cache.Driver {
+	case "", "memory":
+		AppCache = NewMemoryCache()
+		fmt.Println("缓存初始化成功（内存）")
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     AppConfig.Cache.RedisAddr,
+			Password: AppConfig.Cache.RedisPassword,
+			DB:       AppConfig.Cache.RedisDB,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			log.Fatal("Redis 连接失败:", err)
+		}
+		AppCache = &redisCache{client: client}
+		fmt.Println("缓存初始化成功（Redis）")
+	default:
+		log.Fatal("不支持的缓存类型: ", AppConfig.Cache.Driver)
+	}
+}
+
+// memoryEntry 内存缓存条目
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// memoryCache 进程内存缓存，适用于单实例部署
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryCache 创建内存缓存，后台定期清理过期条目
+func NewMemoryCache() Cache {
+	c := &memoryCache{entries: make(map[string]memoryEntry)}
+	go c.cleanup(time.Minute)
+	return c
+}
+
+// Get 读取缓存
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set 写入缓存
+func (c *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	c.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	c.mu.Unlock()
+	return nil
+}
+
+// Delete 删除缓存
+func (c *memoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+	return nil
+}
+
+// cleanup 定期清理过期条目
+func (c *memoryCache) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		c.mu.Lock()
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// redisCache Redis 缓存，兼容 Redis 协议的服务均可使用，适用于多实例部署
+type redisCache struct {
+	client *redis.Client
+}
+
+// Get 读取缓存
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set 写入缓存
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete 删除缓存
+func (c *redisCache) Delete(ctx context.Context, key string) error {
+	return c.client.Del(ctx, key).Err()
+}
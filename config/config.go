@@ -1,15 +1,25 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config 全局配置
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	JWT         JWTConfig
+	Mail        MailConfig
+	Security    SecurityConfig
+	OIDC        OIDCConfig
+	Cache       CacheConfig
+	Competition CompetitionConfig
+	Container   ContainerConfig
 }
 
 // ServerConfig 服务器配置
@@ -30,8 +40,81 @@ type DatabaseConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret     string
-	ExpireTime int // 小时
+	Algorithm       string // 签名算法：HS256（默认）、EdDSA、RS256
+	KeyID           string // 当前签名密钥的 kid
+	Secret          string // HS256 密钥
+	PrivateKeyFile  string // EdDSA/RS256 私钥文件（PEM）
+	VerifyKeys      string // 仅用于验签的历史密钥，格式 kid=值,kid=值；HS256 为密钥，EdDSA/RS256 为公钥 PEM 文件路径
+	AccessTokenTTL  int    // Access Token 有效期（分钟）
+	RefreshTokenTTL int    // Refresh Token 有效期（小时）
+}
+
+// MailConfig 邮件配置（Host 为空时仅在控制台记录收件人与主题）
+type MailConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	SiteURL  string // 前端站点地址，用于拼接邮件中的链接
+	LogBody  bool   // 未配置 Host 时是否在控制台打印邮件正文（含重置密码令牌，仅限本地调试）
+}
+
+// SecurityConfig 安全策略配置
+type SecurityConfig struct {
+	AdminRequire2FA bool // 管理员接口是否要求通过两步验证的会话
+}
+
+// OIDCConfig 统一身份认证（OIDC）配置，提供方列表从 JSON 文件加载
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig
+}
+
+// OIDCProviderConfig 单个 OIDC 提供方配置
+type OIDCProviderConfig struct {
+	Name         string            `json:"name"`         // 提供方标识，用于接口路径
+	DisplayName  string            `json:"display_name"` // 登录页展示名称
+	Issuer       string            `json:"issuer"`       // Issuer 地址，通过 /.well-known/openid-configuration 自动发现
+	ClientID     string            `json:"client_id"`
+	ClientSecret string            `json:"client_secret"`
+	RedirectURL  string            `json:"redirect_url"` // 前端回调页地址
+	Scopes       []string          `json:"scopes"`       // 额外申请的 scope，openid 自动包含
+	SchoolID     int64             `json:"school_id"`    // 固定对应的学校，为 0 时按 school_name 声明匹配
+	Trusted      bool              `json:"trusted"`      // 受信任提供方：新注册的院校赛道用户自动审核通过
+	Claims       map[string]string `json:"claims"`       // 声明映射：username、email、user_name、student_number、school_name → 提供方声明名
+}
+
+// CacheConfig 缓存配置，默认使用进程内存，多实例部署时可切换为 Redis
+type CacheConfig struct {
+	Driver        string // memory（默认）或 redis
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+}
+
+// CompetitionConfig 比赛时间配置，未设置时视为比赛进行中且不倒计时
+type CompetitionConfig struct {
+	Name      string
+	StartTime *time.Time
+	EndTime   *time.Time
+	Paused    bool
+}
+
+// ContainerConfig 题目容器配置
+type ContainerConfig struct {
+	PortMin int    // 宿主机端口池下限（含）
+	PortMax int    // 宿主机端口池上限（含）
+	BindIP  string // 容器端口绑定的宿主机地址
+
+	TeamLimit   int // 每个团队同时运行（含启动中、排队中）的容器上限
+	GlobalLimit int // 平台同时运行（含启动中、预热中）的容器上限，多实例共享数据库中的名额
+
+	ProxyDomain      string // 反向代理根域名（如 chal.example.com），为空时不启用，容器通过 <token>.<域名> 访问
+	ProxyScheme      string // 返回给选手的访问地址协议（http 或 https）
+	ProxyRequireAuth bool   // 是否仅允许容器所属团队通过平台签发的票据访问
+
+	GatewayTeamLimit   int // TCP 中转：每个团队同时保持的 WebSocket 连接上限
+	GatewayIdleTimeout int // TCP 中转：连接双向无数据的超时时间（秒）
 }
 
 var AppConfig *Config
@@ -52,9 +135,60 @@ func InitConfig() {
 			Charset:  getEnv("DB_CHARSET", "utf8mb4"),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "isctf-secret-key-2024"),
-			ExpireTime: 24, // 24小时
+			Algorithm:       getEnv("JWT_ALGORITHM", "HS256"),
+			KeyID:           getEnv("JWT_KEY_ID", "default"),
+			Secret:          getEnv("JWT_SECRET", ""),
+			PrivateKeyFile:  getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			VerifyKeys:      getEnv("JWT_VERIFY_KEYS", ""),
+			AccessTokenTTL:  getEnvInt("JWT_ACCESS_TTL", 15),   // 15分钟
+			RefreshTokenTTL: getEnvInt("JWT_REFRESH_TTL", 168), // 7天
+		},
+		Mail: MailConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "465"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "ISCTF <noreply@isctf.local>"),
+			SiteURL:  getEnv("SITE_URL", "http://localhost:5173"),
+			LogBody:  getEnv("SMTP_LOG_BODY", "false") == "true",
+		},
+		Security: SecurityConfig{
+			AdminRequire2FA: getEnv("ADMIN_REQUIRE_2FA", "false") == "true",
+		},
+		OIDC: OIDCConfig{
+			Providers: loadOIDCProviders(getEnv("OIDC_PROVIDERS_FILE", "")),
+		},
+		Cache: CacheConfig{
+			Driver:        getEnv("CACHE_DRIVER", "memory"),
+			RedisAddr:     getEnv("REDIS_ADDR", "127.0.0.1:6379"),
+			RedisPassword: getEnv("REDIS_PASSWORD", ""),
+			RedisDB:       getEnvInt("REDIS_DB", 0),
 		},
+		Competition: CompetitionConfig{
+			Name:      getEnv("COMPETITION_NAME", "ISCTF"),
+			StartTime: getEnvTime("COMPETITION_START_TIME"),
+			EndTime:   getEnvTime("COMPETITION_END_TIME"),
+			Paused:    getEnv("COMPETITION_PAUSED", "false") == "true",
+		},
+		Container: ContainerConfig{
+			PortMin: getEnvInt("CONTAINER_PORT_MIN", 30000),
+			PortMax: getEnvInt("CONTAINER_PORT_MAX", 40000),
+			BindIP:  getEnv("CONTAINER_BIND_IP", "0.0.0.0"),
+
+			TeamLimit:   getEnvInt("CONTAINER_TEAM_LIMIT", 3),
+			GlobalLimit: getEnvInt("CONTAINER_GLOBAL_LIMIT", 200),
+
+			ProxyDomain:      getEnv("CONTAINER_PROXY_DOMAIN", ""),
+			ProxyScheme:      getEnv("CONTAINER_PROXY_SCHEME", "https"),
+			ProxyRequireAuth: getEnv("CONTAINER_PROXY_REQUIRE_AUTH", "false") == "true",
+
+			GatewayTeamLimit:   getEnvInt("CONTAINER_GATEWAY_TEAM_LIMIT", 10),
+			GatewayIdleTimeout: getEnvInt("CONTAINER_GATEWAY_IDLE_TIMEOUT", 300),
+		},
+	}
+
+	if AppConfig.Container.PortMin > AppConfig.Container.PortMax || AppConfig.Container.PortMax > 65535 {
+		log.Fatalf("容器端口池配置错误: %d-%d", AppConfig.Container.PortMin, AppConfig.Container.PortMax)
 	}
 
 	fmt.Println("配置加载成功")
@@ -69,6 +203,54 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvInt 获取整数类型的环境变量，不存在或格式错误时返回默认值
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvTime 获取时间类型的环境变量（格式 2006-01-02 15:04:05，本地时区），未设置时返回 nil
+func getEnvTime(key string) *time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		log.Fatalf("环境变量 %s 时间格式错误: %v", key, err)
+	}
+	return &t
+}
+
+// loadOIDCProviders 从 JSON 文件加载 OIDC 提供方列表，未配置时返回空列表
+func loadOIDCProviders(path string) []OIDCProviderConfig {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal("读取 OIDC 配置失败:", err)
+	}
+	var providers []OIDCProviderConfig
+	if err := json.Unmarshal(data, &providers); err != nil {
+		log.Fatal("解析 OIDC 配置失败:", err)
+	}
+	names := make(map[string]bool)
+	for _, p := range providers {
+		if p.Name == "" || p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			log.Fatal("OIDC 提供方配置缺少 name/issuer/client_id/redirect_url")
+		}
+		if names[p.Name] {
+			log.Fatal("OIDC 提供方名称重复: ", p.Name)
+		}
+		names[p.Name] = true
+	}
+	return providers
+}
+
 // GetDSN 获取数据库连接字符串
 func GetDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=True&loc=Local",
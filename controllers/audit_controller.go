@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditController 审计日志控制器
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+// NewAuditController 创建审计日志控制器实例
+func NewAuditController() *AuditController {
+	return &AuditController{
+		auditService: services.NewAuditService(),
+	}
+}
+
+// GetAuditLogs 查询审计日志
+func (c *AuditController) GetAuditLogs(ctx *gin.Context) {
+	var req dto.AuditLogListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.auditService.GetAuditLogs(&req)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取审计日志失败: "+err.Error())
+		return
+	}
+	utils.Success(ctx, result)
+}
+
+// operatorFromContext 根据登录信息构造管理操作执行者，用于写入审计日志
+func operatorFromContext(ctx *gin.Context) *services.Operator {
+	return &services.Operator{
+		UserID:   ctx.GetInt64("user_id"),
+		Username: ctx.GetString("username"),
+		Role:     ctx.GetString("role"),
+		IP:       ctx.ClientIP(),
+	}
+}
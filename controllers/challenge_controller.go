@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"isctf/config"
 	"isctf/dto"
+	"isctf/models"
 	"isctf/services"
 	"isctf/utils"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,9 +31,9 @@ func (c *ChallengeController) Create(ctx *gin.Context) {
 		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
 		return
 	}
-	chal, err := c.chalService.CreateChallenge(&req)
+	chal, err := c.chalService.CreateChallenge(operatorFromContext(ctx), &req)
 	if err != nil {
-		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		c.handleManageError(ctx, err)
 		return
 	}
 	utils.Success(ctx, chal)
@@ -38,20 +41,119 @@ func (c *ChallengeController) Create(ctx *gin.Context) {
 
 // Update 更新题目
 func (c *ChallengeController) Update(ctx *gin.Context) {
-	// 略: 实现更新逻辑
-	utils.Success(ctx, nil)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	var req dto.ChallengeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	result, err := c.chalService.UpdateChallenge(operatorFromContext(ctx), id, &req)
+	if err != nil {
+		c.handleManageError(ctx, err)
+		return
+	}
+	if len(result.Warnings) > 0 {
+		utils.SuccessWithMsg(ctx, "更新题目成功，"+strings.Join(result.Warnings, "；"), result)
+		return
+	}
+	utils.SuccessWithMsg(ctx, "更新题目成功", result)
 }
 
 // Delete 删除题目
 func (c *ChallengeController) Delete(ctx *gin.Context) {
-	// 略: 实现删除逻辑
-	utils.Success(ctx, nil)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	if err := c.chalService.DeleteChallenge(operatorFromContext(ctx), id); err != nil {
+		c.handleManageError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, "删除题目成功", nil)
 }
 
 // UpdateState 更新状态
 func (c *ChallengeController) UpdateState(ctx *gin.Context) {
-	// 略: 实现更新状态逻辑
-	utils.Success(ctx, nil)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	var req dto.UpdateChallengeStateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := c.chalService.UpdateChallengeState(operatorFromContext(ctx), id, req.State); err != nil {
+		c.handleManageError(ctx, err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, "更新状态成功", nil)
+}
+
+// handleManageError 处理题目管理错误
+func (c *ChallengeController) handleManageError(ctx *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidServiceSpec) {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+		return
+	}
+	switch err.Error() {
+	case "题目不存在":
+		utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+	case "静态题目必须设置 Flag", "动态题目必须设置 Docker 镜像", "最低分值不能高于初始分值":
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+	default:
+		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+	}
+}
+
+// ScheduleRelease 设置题目定时放出
+func (c *ChallengeController) ScheduleRelease(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	var req dto.ScheduleReleaseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	chal, err := c.chalService.ScheduleRelease(operatorFromContext(ctx), id, &req)
+	if err != nil {
+		switch err.Error() {
+		case "题目不存在":
+			utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+		case "放出时间必须晚于当前时间":
+			utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+		default:
+			utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		}
+		return
+	}
+	utils.SuccessWithMsg(ctx, "设置定时放出成功", chal)
+}
+
+// GetUpcomingReleases 获取待放出题目列表
+func (c *ChallengeController) GetUpcomingReleases(ctx *gin.Context) {
+	list, err := c.chalService.GetUpcomingReleases()
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(ctx, list)
 }
 
 // GetList 获取题目列表
@@ -59,10 +161,7 @@ func (c *ChallengeController) GetList(ctx *gin.Context) {
 	var req dto.ChallengeListRequest
 	ctx.ShouldBindQuery(&req)
 
-	role, _ := ctx.Get("role")
-	isAdmin := (role == "admin" || role == "super_admin")
-
-	list, total, err := c.chalService.GetChallengeList(&req, isAdmin)
+	list, total, err := c.chalService.GetChallengeList(&req, viewerTeamID(ctx))
 	if err != nil {
 		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
 		return
@@ -75,17 +174,79 @@ func (c *ChallengeController) GetDetail(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, _ := strconv.ParseInt(idStr, 10, 64)
 
-	role, _ := ctx.Get("role")
-	isAdmin := (role == "admin" || role == "super_admin")
+	chal, err := c.chalService.GetDetail(id, viewerTeamID(ctx))
+	if err != nil {
+		c.handleAccessError(ctx, err)
+		return
+	}
+	utils.Success(ctx, chal)
+}
 
-	chal, err := c.chalService.GetDetail(id, isAdmin)
+// GetAdminList 管理端获取题目列表（含 Flag、镜像及隐藏题目）
+// 仅拥有 challenge.write.own 权限的出题人只能看到自己创建的题目
+func (c *ChallengeController) GetAdminList(ctx *gin.Context) {
+	var req dto.ChallengeListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+		return
+	}
+
+	canReadAll, err := services.NewPermissionService().HasPermission(ctx.GetString("role"), models.PermChallengeWrite)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "权限验证失败")
+		return
+	}
+	var createdBy int64
+	if !canReadAll {
+		createdBy = ctx.GetInt64("user_id")
+	}
+
+	list, total, err := c.chalService.GetAdminChallengeList(&req, createdBy)
 	if err != nil {
 		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
 		return
 	}
+	utils.Success(ctx, gin.H{"list": list, "total": total})
+}
+
+// GetAdminDetail 管理端获取题目详情
+func (c *ChallengeController) GetAdminDetail(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	chal, err := c.chalService.GetAdminDetail(id)
+	if err != nil {
+		c.handleManageError(ctx, err)
+		return
+	}
 	utils.Success(ctx, chal)
 }
 
+// viewerTeamID 获取当前访问者所在团队ID，未登录或未加入团队返回 0
+func viewerTeamID(ctx *gin.Context) int64 {
+	userID := ctx.GetInt64("user_id")
+	if userID == 0 {
+		return 0
+	}
+	teamDetail, err := services.NewTeamService().GetMyTeam(userID)
+	if err != nil {
+		return 0
+	}
+	return teamDetail.ID
+}
+
+// handleAccessError 处理题目访问错误
+func (c *ChallengeController) handleAccessError(ctx *gin.Context, err error) {
+	if err.Error() == "题目尚未解锁" {
+		utils.Error(ctx, utils.CHALLENGE_LOCKED)
+		return
+	}
+	utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+}
+
 // StartContainer 启动容器
 func (c *ChallengeController) StartContainer(ctx *gin.Context) {
 	userID := ctx.GetInt64("user_id")
@@ -100,9 +261,26 @@ func (c *ChallengeController) StartContainer(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	chalID, _ := strconv.ParseInt(idStr, 10, 64)
 
-	container, err := c.chalService.StartContainer(userID, teamDetail.ID, chalID)
+	// queue=true 时全局容量已满会加入排队，否则直接返回错误
+	queue := ctx.Query("queue") == "true"
+	container, entry, err := c.chalService.StartContainer(userID, teamDetail.ID, chalID, queue)
 	if err != nil {
-		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		c.handleContainerError(ctx, err)
+		return
+	}
+	if entry != nil {
+		utils.SuccessWithMsg(ctx, "容器资源已满，已加入排队", gin.H{
+			"status": "queued",
+			"queue":  entry,
+		})
+		return
+	}
+
+	// 启用反向代理时 http 端口返回子域名访问地址，其余端口仍返回宿主机端口映射
+	proxyService := services.NewContainerProxyService()
+	accessURL, err := proxyService.ContainerURL(container)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "生成访问地址失败: "+err.Error())
 		return
 	}
 
@@ -112,14 +290,89 @@ func (c *ChallengeController) StartContainer(ctx *gin.Context) {
 		hostIP = ctx.Request.Host // 开发环境尝试取请求 Host
 	}
 
-	utils.Success(ctx, gin.H{
+	resp := gin.H{
 		"container_id":   container.ID,
 		"status":         "running",
 		"host":           hostIP,
-		"ports":          container.HostMapping,
+		"ports":          proxyService.DirectPorts(container),
 		"expires_at":     container.EndTime,
 		"time_remaining": "1h", // 简化
-	})
+	}
+	if accessURL != "" {
+		resp["url"] = accessURL
+	}
+	utils.Success(ctx, resp)
+}
+
+// GetContainerURL 重新获取容器反向代理访问地址（开启访问鉴权时票据为一次性，每次打开前需重新获取）
+func (c *ChallengeController) GetContainerURL(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	teamDetail, err := services.NewTeamService().GetMyTeam(userID)
+	if err != nil {
+		utils.Error(ctx, utils.TEAM_NOT_JOINED)
+		return
+	}
+	chalID, _ := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	accessURL, err := services.NewContainerProxyService().GetContainerURL(teamDetail.ID, chalID)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		return
+	}
+	if accessURL == "" {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "该容器不支持反向代理访问")
+		return
+	}
+	utils.Success(ctx, gin.H{"url": accessURL})
+}
+
+// GetContainerQueue 轮询容器排队状态（排队期间需持续轮询，否则会被自动取消）
+func (c *ChallengeController) GetContainerQueue(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	teamDetail, err := services.NewTeamService().GetMyTeam(userID)
+	if err != nil {
+		utils.Error(ctx, utils.TEAM_NOT_JOINED)
+		return
+	}
+	chalID, _ := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	resp, err := c.chalService.GetContainerQueue(teamDetail.ID, chalID)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+		return
+	}
+	utils.Success(ctx, resp)
+}
+
+// CancelContainerQueue 取消容器排队
+func (c *ChallengeController) CancelContainerQueue(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	teamDetail, err := services.NewTeamService().GetMyTeam(userID)
+	if err != nil {
+		utils.Error(ctx, utils.TEAM_NOT_JOINED)
+		return
+	}
+	chalID, _ := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err := c.chalService.CancelContainerQueue(teamDetail.ID, chalID); err != nil {
+		utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+		return
+	}
+	utils.SuccessWithMsg(ctx, "已取消排队", nil)
+}
+
+// handleContainerError 处理容器启动错误
+func (c *ChallengeController) handleContainerError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPortPoolExhausted):
+		utils.Error(ctx, utils.CONTAINER_PORT_EXHAUSTED)
+	case errors.Is(err, services.ErrContainerTeamLimit):
+		utils.Error(ctx, utils.CONTAINER_TEAM_LIMIT)
+	case errors.Is(err, services.ErrContainerCapacityFull):
+		utils.Error(ctx, utils.CONTAINER_CAPACITY_FULL)
+	default:
+		c.handleAccessError(ctx, err)
+	}
 }
 
 // StopContainer 停止容器
@@ -177,8 +430,8 @@ func (c *ChallengeController) SubmitFlag(ctx *gin.Context) {
 
 	correct, score, err := c.chalService.SubmitFlag(userID, teamDetail.ID, chalID, req.Flag, ctx.ClientIP())
 	if err != nil {
-		// 可能是已解答或题目不存在
-		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		// 可能是已解答、题目不存在或尚未解锁
+		c.handleAccessError(ctx, err)
 		return
 	}
 
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"isctf/services"
+	"isctf/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// gatewayUpgrader WebSocket 升级配置
+// 鉴权依赖 Authorization 头，浏览器跨站无法伪造，因此不校验 Origin
+var gatewayUpgrader = websocket.Upgrader{
+	ReadBufferSize:  32 * 1024,
+	WriteBufferSize: 32 * 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// ContainerGatewayController 容器 TCP 中转控制器
+type ContainerGatewayController struct {
+	gatewayService *services.ContainerGatewayService
+}
+
+// NewContainerGatewayController 创建容器 TCP 中转控制器实例
+func NewContainerGatewayController() *ContainerGatewayController {
+	return &ContainerGatewayController{
+		gatewayService: services.NewContainerGatewayService(),
+	}
+}
+
+// Connect 建立 TCP-over-WebSocket 中转连接
+// 仅容器所属团队成员可连接，二进制消息即原始 TCP 字节流，协议说明见 ISCTF.md 容器管理模块
+func (c *ContainerGatewayController) Connect(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	teamDetail, err := services.NewTeamService().GetMyTeam(userID)
+	if err != nil {
+		utils.Error(ctx, utils.TEAM_NOT_JOINED)
+		return
+	}
+	containerID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.Error(ctx, utils.INVALID_PARAMS)
+		return
+	}
+
+	target, err := c.gatewayService.Resolve(teamDetail.ID, containerID, ctx.Query("port"))
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.FORBIDDEN, err.Error())
+		return
+	}
+	release, err := c.gatewayService.Acquire(teamDetail.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrGatewayTeamLimit) {
+			utils.Error(ctx, utils.CONTAINER_GATEWAY_LIMIT)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		return
+	}
+	defer release()
+
+	ws, err := gatewayUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已写回 HTTP 错误响应
+		return
+	}
+	if err := c.gatewayService.Relay(ws, target); err != nil {
+		fmt.Printf("容器 %d TCP 中转失败: %v\n", target.ContainerID, err)
+	}
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DashboardController 比赛大屏控制器
+type DashboardController struct {
+	dashboardService *services.DashboardService
+}
+
+// NewDashboardController 创建比赛大屏控制器实例
+func NewDashboardController() *DashboardController {
+	return &DashboardController{
+		dashboardService: services.NewDashboardService(),
+	}
+}
+
+// GetOverview 获取大屏聚合数据（公开）
+func (c *DashboardController) GetOverview(ctx *gin.Context) {
+	resp, err := c.dashboardService.GetOverview()
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取大屏数据失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, resp)
+}
+
+// GetTrend 获取得分趋势图数据（公开）
+func (c *DashboardController) GetTrend(ctx *gin.Context) {
+	var req dto.DashboardTrendRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := c.dashboardService.GetTrend(&req)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取得分趋势失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, resp)
+}
+
+// GetSolveMatrix 获取解题矩阵（公开）
+func (c *DashboardController) GetSolveMatrix(ctx *gin.Context) {
+	var req dto.SolveMatrixRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	resp, err := c.dashboardService.GetSolveMatrix(&req)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取解题矩阵失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, resp)
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HintController 题目提示控制器
+type HintController struct {
+	hintService *services.HintService
+}
+
+// NewHintController 创建提示控制器实例
+func NewHintController() *HintController {
+	return &HintController{
+		hintService: services.NewHintService(),
+	}
+}
+
+// GetHints 获取题目提示列表（选手）
+func (c *HintController) GetHints(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	chalID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	// 未加入团队时只能看到免费提示内容
+	var teamID int64
+	if teamDetail, err := services.NewTeamService().GetMyTeam(userID); err == nil {
+		teamID = teamDetail.ID
+	}
+
+	list, err := c.hintService.GetHints(teamID, chalID)
+	if err != nil {
+		if err.Error() == "题目尚未解锁" {
+			utils.Error(ctx, utils.CHALLENGE_LOCKED)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(ctx, list)
+}
+
+// UnlockHint 解锁提示（扣除团队分数）
+func (c *HintController) UnlockHint(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	chalID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+	hintID, err := strconv.ParseInt(ctx.Param("hint_id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的提示ID")
+		return
+	}
+
+	teamDetail, err := services.NewTeamService().GetMyTeam(userID)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.TEAM_NOT_JOINED, "未加入团队无法解锁提示")
+		return
+	}
+
+	hint, err := c.hintService.UnlockHint(userID, teamDetail.ID, chalID, hintID)
+	if err != nil {
+		switch err.Error() {
+		case "提示不存在":
+			utils.Error(ctx, utils.HINT_NOT_EXIST)
+		case "已解锁该提示":
+			utils.Error(ctx, utils.HINT_ALREADY_UNLOCKED)
+		case "团队分数不足，无法解锁提示":
+			utils.Error(ctx, utils.HINT_SCORE_NOT_ENOUGH)
+		case "题目尚未解锁":
+			utils.Error(ctx, utils.CHALLENGE_LOCKED)
+		default:
+			utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		}
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "解锁提示成功", hint)
+}
+
+// GetAdminHints 获取题目提示及解锁记录（管理员）
+func (c *HintController) GetAdminHints(ctx *gin.Context) {
+	chalID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	list, err := c.hintService.GetAdminHints(chalID)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取提示列表失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, list)
+}
+
+// CreateHint 创建题目提示（管理员）
+func (c *HintController) CreateHint(ctx *gin.Context) {
+	chalID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	var req dto.HintRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	hint, err := c.hintService.CreateHint(operatorFromContext(ctx), chalID, &req)
+	if err != nil {
+		if err.Error() == "题目不存在" {
+			utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "创建提示失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "创建提示成功", hint)
+}
+
+// UpdateHint 修改题目提示（管理员）
+func (c *HintController) UpdateHint(ctx *gin.Context) {
+	chalID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+	hintID, err := strconv.ParseInt(ctx.Param("hint_id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的提示ID")
+		return
+	}
+
+	var req dto.HintRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	hint, err := c.hintService.UpdateHint(operatorFromContext(ctx), chalID, hintID, &req)
+	if err != nil {
+		if err.Error() == "提示不存在" {
+			utils.Error(ctx, utils.HINT_NOT_EXIST)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "修改提示失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "修改提示成功", hint)
+}
+
+// DeleteHint 删除题目提示（管理员）
+func (c *HintController) DeleteHint(ctx *gin.Context) {
+	chalID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+	hintID, err := strconv.ParseInt(ctx.Param("hint_id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的提示ID")
+		return
+	}
+
+	if err := c.hintService.DeleteHint(operatorFromContext(ctx), chalID, hintID); err != nil {
+		if err.Error() == "提示不存在" {
+			utils.Error(ctx, utils.HINT_NOT_EXIST)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "删除提示失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "删除提示成功", nil)
+}
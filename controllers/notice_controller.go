@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoticeController 公告控制器
+type NoticeController struct {
+	noticeService *services.NoticeService
+}
+
+// NewNoticeController 创建公告控制器实例
+func NewNoticeController() *NoticeController {
+	return &NoticeController{
+		noticeService: services.NewNoticeService(),
+	}
+}
+
+// GetNotices 获取公告列表（公开）
+// 关联题目的公告按访问者团队的题目可见范围过滤
+// 支持 ETag 轮询：客户端携带 If-None-Match，公告无变化时返回 304
+func (c *NoticeController) GetNotices(ctx *gin.Context) {
+	var req dto.NoticeListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	teamID := viewerTeamID(ctx)
+	version, err := c.noticeService.GetNoticeVersion(teamID)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取公告列表失败: "+err.Error())
+		return
+	}
+	etag := fmt.Sprintf(`W/"%x"`, sha1.Sum([]byte(version+"?"+ctx.Request.URL.RawQuery)))
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Vary", "Authorization")
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	result, err := c.noticeService.GetNoticeList(&req, false, teamID)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取公告列表失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, result)
+}
+
+// GetNoticeByID 获取公告详情（公开）
+func (c *NoticeController) GetNoticeByID(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的公告ID")
+		return
+	}
+
+	notice, err := c.noticeService.GetNoticeByID(id, false, viewerTeamID(ctx))
+	if err != nil {
+		if err.Error() == "公告不存在" {
+			utils.Error(ctx, utils.NOTICE_NOT_EXIST)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取公告详情失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, notice)
+}
+
+// GetAdminNotices 获取公告列表（管理员，包含草稿与归档）
+func (c *NoticeController) GetAdminNotices(ctx *gin.Context) {
+	var req dto.NoticeListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.noticeService.GetNoticeList(&req, true, 0)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取公告列表失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, result)
+}
+
+// CreateNotice 发布公告（管理员）
+func (c *NoticeController) CreateNotice(ctx *gin.Context) {
+	if _, exists := ctx.Get("user_id"); !exists {
+		utils.Error(ctx, utils.UNAUTHORIZED)
+		return
+	}
+
+	var req dto.NoticeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	notice, err := c.noticeService.CreateNotice(operatorFromContext(ctx), &req)
+	if err != nil {
+		if err.Error() == "关联的题目不存在" {
+			utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "发布公告失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "发布公告成功", notice)
+}
+
+// UpdateNotice 修改公告（管理员）
+func (c *NoticeController) UpdateNotice(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的公告ID")
+		return
+	}
+
+	var req dto.NoticeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	notice, err := c.noticeService.UpdateNotice(operatorFromContext(ctx), id, &req)
+	if err != nil {
+		if err.Error() == "公告不存在" {
+			utils.Error(ctx, utils.NOTICE_NOT_EXIST)
+			return
+		}
+		if err.Error() == "关联的题目不存在" {
+			utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "修改公告失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "修改公告成功", notice)
+}
+
+// UpdateNoticeTop 置顶/取消置顶（管理员）
+func (c *NoticeController) UpdateNoticeTop(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的公告ID")
+		return
+	}
+
+	var req dto.UpdateNoticeTopRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.noticeService.UpdateNoticeTop(operatorFromContext(ctx), id, *req.IsTop); err != nil {
+		if err.Error() == "公告不存在" {
+			utils.Error(ctx, utils.NOTICE_NOT_EXIST)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "更新置顶状态失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "更新置顶状态成功", nil)
+}
+
+// DeleteNotice 删除公告（管理员，软删除）
+func (c *NoticeController) DeleteNotice(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的公告ID")
+		return
+	}
+
+	if err := c.noticeService.DeleteNotice(operatorFromContext(ctx), id); err != nil {
+		if err.Error() == "公告不存在" {
+			utils.Error(ctx, utils.NOTICE_NOT_EXIST)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "删除公告失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "删除公告成功", nil)
+}
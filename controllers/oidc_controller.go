@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCController 统一身份认证控制器
+type OIDCController struct {
+	oidcService *services.OIDCService
+}
+
+// NewOIDCController 创建统一身份认证控制器实例
+func NewOIDCController() *OIDCController {
+	return &OIDCController{
+		oidcService: services.NewOIDCService(),
+	}
+}
+
+// GetProviders 获取可用的统一身份认证提供方
+func (c *OIDCController) GetProviders(ctx *gin.Context) {
+	utils.Success(ctx, c.oidcService.GetProviders())
+}
+
+// Authorize 发起统一身份认证登录
+func (c *OIDCController) Authorize(ctx *gin.Context) {
+	result, err := c.oidcService.Authorize(ctx.Param("provider"), 0)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.Success(ctx, result)
+}
+
+// Callback 统一身份认证登录回调
+func (c *OIDCController) Callback(ctx *gin.Context) {
+	var req dto.OIDCCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.oidcService.Login(ctx.Param("provider"), &req, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	if result.TwoFactorRequired {
+		utils.SuccessWithMsg(ctx, "请输入两步验证码", result)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "登录成功", result)
+}
+
+// GetMyIdentities 获取我绑定的外部身份
+func (c *OIDCController) GetMyIdentities(ctx *gin.Context) {
+	list, err := c.oidcService.GetIdentities(ctx.GetInt64("user_id"))
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取绑定信息失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, list)
+}
+
+// AuthorizeLink 发起外部身份绑定
+func (c *OIDCController) AuthorizeLink(ctx *gin.Context) {
+	result, err := c.oidcService.Authorize(ctx.Param("provider"), ctx.GetInt64("user_id"))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.Success(ctx, result)
+}
+
+// LinkCallback 外部身份绑定回调
+func (c *OIDCController) LinkCallback(ctx *gin.Context) {
+	var req dto.OIDCCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.oidcService.Link(ctx.Param("provider"), ctx.GetInt64("user_id"), &req); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "绑定成功", nil)
+}
+
+// Unlink 解除外部身份绑定
+func (c *OIDCController) Unlink(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的绑定ID")
+		return
+	}
+
+	if err := c.oidcService.Unlink(ctx.GetInt64("user_id"), id); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "已解除绑定", nil)
+}
+
+// handleError 统一处理统一身份认证错误
+func (c *OIDCController) handleError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "认证提供方不存在", "绑定记录不存在":
+		utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+	case "认证状态无效或已过期，请重新发起登录", "统一身份认证失败，请重新登录":
+		utils.ErrorWithMsg(ctx, utils.TOKEN_INVALID, err.Error())
+	case "该外部身份已绑定其他账号", "已绑定该认证提供方", "该邮箱已注册，请使用密码登录后在个人中心绑定统一身份认证":
+		utils.ErrorWithMsg(ctx, utils.CONFLICT, err.Error())
+	case "用户已被封禁", "该学校已被封禁，无法注册":
+		utils.ErrorWithMsg(ctx, utils.FORBIDDEN, err.Error())
+	default:
+		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+	}
+}
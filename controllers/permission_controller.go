@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionController 角色权限控制器
+type PermissionController struct {
+	permissionService *services.PermissionService
+}
+
+// NewPermissionController 创建角色权限控制器实例
+func NewPermissionController() *PermissionController {
+	return &PermissionController{
+		permissionService: services.NewPermissionService(),
+	}
+}
+
+// GetRolePermissions 获取权限定义及各角色权限
+func (c *PermissionController) GetRolePermissions(ctx *gin.Context) {
+	result, err := c.permissionService.GetRolePermissions()
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取角色权限失败: "+err.Error())
+		return
+	}
+	utils.Success(ctx, result)
+}
+
+// SetRolePermissions 覆盖设置角色权限
+func (c *PermissionController) SetRolePermissions(ctx *gin.Context) {
+	var req dto.SetRolePermissionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.permissionService.SetRolePermissions(operatorFromContext(ctx), ctx.Param("role"), &req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "设置角色权限失败: "+err.Error())
+		return
+	}
+	utils.SuccessWithMsg(ctx, "角色权限已更新", nil)
+}
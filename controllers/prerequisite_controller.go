@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrerequisiteController 题目前置依赖控制器
+type PrerequisiteController struct {
+	prerequisiteService *services.PrerequisiteService
+}
+
+// NewPrerequisiteController 创建前置依赖控制器实例
+func NewPrerequisiteController() *PrerequisiteController {
+	return &PrerequisiteController{
+		prerequisiteService: services.NewPrerequisiteService(),
+	}
+}
+
+// GetGraph 获取题目依赖图（管理员）
+func (c *PrerequisiteController) GetGraph(ctx *gin.Context) {
+	graph, err := c.prerequisiteService.GetGraph()
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取依赖图失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, graph)
+}
+
+// SetPrerequisites 设置题目解锁条件（管理员）
+func (c *PrerequisiteController) SetPrerequisites(ctx *gin.Context) {
+	chalID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的题目ID")
+		return
+	}
+
+	var req dto.PrerequisiteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	node, err := c.prerequisiteService.SetPrerequisites(operatorFromContext(ctx), chalID, &req)
+	if err != nil {
+		switch err.Error() {
+		case "题目不存在":
+			utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+		case "题目依赖存在循环":
+			utils.Error(ctx, utils.CHALLENGE_PREREQUISITE_LOOP)
+		case "题目不能依赖自身", "前置题目不存在":
+			utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+		default:
+			utils.ErrorWithMsg(ctx, utils.ERROR, "设置解锁条件失败: "+err.Error())
+		}
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "设置解锁条件成功", node)
+}
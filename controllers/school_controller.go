@@ -37,7 +37,7 @@ func (c *SchoolController) CreateSchool(ctx *gin.Context) {
 		return
 	}
 
-	school, err := c.schoolService.CreateSchool(&req)
+	school, err := c.schoolService.CreateSchool(operatorFromContext(ctx), &req)
 	if err != nil {
 		if err.Error() == "学校名称已存在" {
 			utils.ErrorWithMsg(ctx, utils.SCHOOL_ALREADY_EXIST, err.Error())
@@ -169,7 +169,7 @@ func (c *SchoolController) UpdateSchool(ctx *gin.Context) {
 		return
 	}
 
-	school, err := c.schoolService.UpdateSchool(id, &req)
+	school, err := c.schoolService.UpdateSchool(operatorFromContext(ctx), id, &req)
 	if err != nil {
 		if err.Error() == "学校不存在" {
 			utils.ErrorWithMsg(ctx, utils.SCHOOL_NOT_EXIST, err.Error())
@@ -219,7 +219,7 @@ func (c *SchoolController) UpdateSchoolStatus(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.schoolService.UpdateSchoolStatus(id, req.Status); err != nil {
+	if err := c.schoolService.UpdateSchoolStatus(operatorFromContext(ctx), id, req.Status); err != nil {
 		if err.Error() == "学校不存在" {
 			utils.ErrorWithMsg(ctx, utils.SCHOOL_NOT_EXIST, err.Error())
 			return
@@ -248,7 +248,7 @@ func (c *SchoolController) DeleteSchool(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.schoolService.DeleteSchool(id); err != nil {
+	if err := c.schoolService.DeleteSchool(operatorFromContext(ctx), id); err != nil {
 		if err.Error() == "学校不存在" {
 			utils.ErrorWithMsg(ctx, utils.SCHOOL_NOT_EXIST, err.Error())
 			return
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SchoolRosterController 学校名单控制器
+type SchoolRosterController struct {
+	rosterService *services.SchoolRosterService
+}
+
+// NewSchoolRosterController 创建学校名单控制器实例
+func NewSchoolRosterController() *SchoolRosterController {
+	return &SchoolRosterController{
+		rosterService: services.NewSchoolRosterService(),
+	}
+}
+
+// GetRoster 获取学校名单（管理员）
+func (c *SchoolRosterController) GetRoster(ctx *gin.Context) {
+	schoolID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的学校ID")
+		return
+	}
+
+	var req dto.RosterListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.rosterService.GetRoster(schoolID, &req)
+	if err != nil {
+		if err.Error() == "学校不存在" {
+			utils.Error(ctx, utils.SCHOOL_NOT_EXIST)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取名单失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, result)
+}
+
+// AddEntries 批量添加学校名单（管理员）
+func (c *SchoolRosterController) AddEntries(ctx *gin.Context) {
+	schoolID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的学校ID")
+		return
+	}
+
+	var req dto.AddRosterRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.rosterService.AddEntries(operatorFromContext(ctx), schoolID, &req)
+	if err != nil {
+		if err.Error() == "学校不存在" {
+			utils.Error(ctx, utils.SCHOOL_NOT_EXIST)
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "添加名单失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "添加名单成功", result)
+}
+
+// DeleteEntry 删除学校名单条目（管理员）
+func (c *SchoolRosterController) DeleteEntry(ctx *gin.Context) {
+	schoolID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的学校ID")
+		return
+	}
+	entryID, err := strconv.ParseInt(ctx.Param("entry_id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的名单条目ID")
+		return
+	}
+
+	if err := c.rosterService.DeleteEntry(operatorFromContext(ctx), schoolID, entryID); err != nil {
+		if err.Error() == "名单条目不存在" {
+			utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "删除名单条目失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "删除名单条目成功", nil)
+}
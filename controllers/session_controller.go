@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionController 登录会话控制器
+type SessionController struct {
+	sessionService *services.SessionService
+}
+
+// NewSessionController 创建会话控制器实例
+func NewSessionController() *SessionController {
+	return &SessionController{
+		sessionService: services.NewSessionService(),
+	}
+}
+
+// Refresh 使用 Refresh Token 换取新的 Token 对
+func (c *SessionController) Refresh(ctx *gin.Context) {
+	var req dto.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.sessionService.Refresh(req.RefreshToken, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		if err.Error() == "登录状态已失效，请重新登录" {
+			utils.ErrorWithMsg(ctx, utils.TOKEN_INVALID, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "刷新Token失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, result)
+}
+
+// Logout 退出登录，吊销当前会话
+func (c *SessionController) Logout(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	sessionID := ctx.GetInt64("session_id")
+
+	if err := c.sessionService.RevokeSession(userID, sessionID); err != nil {
+		if err.Error() == "会话不存在或已失效" {
+			utils.ErrorWithMsg(ctx, utils.TOKEN_INVALID, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "退出登录失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "已退出登录", nil)
+}
+
+// GetMySessions 获取我的登录会话列表
+func (c *SessionController) GetMySessions(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+	sessionID := ctx.GetInt64("session_id")
+
+	list, err := c.sessionService.ListSessions(userID, sessionID)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "获取会话列表失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, list)
+}
+
+// RevokeMySession 远程下线指定会话
+func (c *SessionController) RevokeMySession(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的会话ID")
+		return
+	}
+
+	if err := c.sessionService.RevokeSession(ctx.GetInt64("user_id"), id); err != nil {
+		if err.Error() == "会话不存在或已失效" {
+			utils.ErrorWithMsg(ctx, utils.NOT_FOUND, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "下线会话失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "会话已下线", nil)
+}
+
+// GetJWKS 公开签名公钥（JWKS 标准格式，不使用统一响应包装）
+func (c *SessionController) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, utils.GetJWKS())
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportFileSize 导入文件大小上限（5MB）
+const maxImportFileSize = 5 << 20
+
+// StudentImportController 学生批量导入控制器
+type StudentImportController struct {
+	importService *services.StudentImportService
+}
+
+// NewStudentImportController 创建学生导入控制器实例
+func NewStudentImportController() *StudentImportController {
+	return &StudentImportController{
+		importService: services.NewStudentImportService(),
+	}
+}
+
+// ImportStudents 批量导入学生（管理员/院校负责人）
+func (c *StudentImportController) ImportStudents(ctx *gin.Context) {
+	schoolID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的学校ID")
+		return
+	}
+
+	var req dto.StudentImportRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "请上传 CSV 或 XLSX 文件")
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "文件大小不能超过 5MB")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "读取文件失败: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	result, err := c.importService.ImportStudents(operatorFromContext(ctx), schoolID, fileHeader.Filename, file, &req)
+	if err != nil {
+		switch err.Error() {
+		case "学校不存在":
+			utils.Error(ctx, utils.SCHOOL_NOT_EXIST)
+		case "该学校已被封禁，无法导入":
+			utils.ErrorWithMsg(ctx, utils.SCHOOL_SUSPENDED, err.Error())
+		case "无权管理其他学校的学生":
+			utils.ErrorWithMsg(ctx, utils.PERMISSION_DENIED, err.Error())
+		default:
+			utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "导入失败: "+err.Error())
+		}
+		return
+	}
+
+	msg := "导入完成"
+	if req.DryRun {
+		msg = "校验完成（未写入数据）"
+	}
+	utils.SuccessWithMsg(ctx, msg, result)
+}
@@ -311,7 +311,7 @@ func (c *TeamController) UpdateTeamStatus(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.teamService.UpdateTeamStatus(teamID, req.Status); err != nil {
+	if err := c.teamService.UpdateTeamStatus(operatorFromContext(ctx), teamID, req.Status); err != nil {
 		if err.Error() == "团队不存在" {
 			utils.ErrorWithMsg(ctx, utils.TEAM_NOT_EXIST, err.Error())
 			return
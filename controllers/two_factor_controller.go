@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"isctf/dto"
+	"isctf/services"
+	"isctf/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorController 两步验证控制器
+type TwoFactorController struct {
+	twoFactorService *services.TwoFactorService
+}
+
+// NewTwoFactorController 创建两步验证控制器实例
+func NewTwoFactorController() *TwoFactorController {
+	return &TwoFactorController{
+		twoFactorService: services.NewTwoFactorService(),
+	}
+}
+
+// GetStatus 获取两步验证状态
+func (c *TwoFactorController) GetStatus(ctx *gin.Context) {
+	result, err := c.twoFactorService.GetStatus(ctx.GetInt64("user_id"), ctx.GetBool("two_factor"))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.Success(ctx, result)
+}
+
+// Setup 获取两步验证绑定信息（密钥与二维码链接）
+func (c *TwoFactorController) Setup(ctx *gin.Context) {
+	result, err := c.twoFactorService.Setup(ctx.GetInt64("user_id"))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.Success(ctx, result)
+}
+
+// Enable 校验验证码并启用两步验证
+func (c *TwoFactorController) Enable(ctx *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.twoFactorService.Enable(ctx.GetInt64("user_id"), ctx.GetInt64("session_id"), req.Code)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "两步验证已启用，请妥善保存恢复码，其他设备已退出登录", result)
+}
+
+// Disable 关闭两步验证
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+	var req dto.DisableTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.twoFactorService.Disable(ctx.GetInt64("user_id"), &req); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "两步验证已关闭", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.twoFactorService.RegenerateRecoveryCodes(ctx.GetInt64("user_id"), req.Code)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "恢复码已重新生成，旧恢复码已作废", result)
+}
+
+// handleError 统一处理两步验证错误
+func (c *TwoFactorController) handleError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "用户不存在":
+		utils.Error(ctx, utils.USER_NOT_EXIST)
+	case "密码错误":
+		utils.ErrorWithMsg(ctx, utils.PASSWORD_ERROR, err.Error())
+	case "验证码错误", "验证失败次数过多，请15分钟后重试":
+		utils.ErrorWithMsg(ctx, utils.TWO_FACTOR_INVALID, err.Error())
+	case "两步验证已启用", "两步验证未启用", "请先获取两步验证绑定信息":
+		utils.ErrorWithMsg(ctx, utils.CONFLICT, err.Error())
+	default:
+		utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+	}
+}
@@ -74,7 +74,11 @@ func (c *UserController) RegisterSchool(ctx *gin.Context) {
 		return
 	}
 
-	utils.SuccessWithMsg(ctx, "注册成功，请等待院校负责人审核", gin.H{
+	msg := "注册成功，请等待院校负责人审核"
+	if user.VerifyStatus == "approved" {
+		msg = "注册成功，已通过学校名单自动核验"
+	}
+	utils.SuccessWithMsg(ctx, msg, gin.H{
 		"id":            user.ID,
 		"username":      user.Username,
 		"email":         user.Email,
@@ -131,7 +135,7 @@ func (c *UserController) Login(ctx *gin.Context) {
 	// 获取客户端IP
 	ip := ctx.ClientIP()
 
-	result, err := c.userService.Login(&req, ip)
+	result, err := c.userService.Login(&req, ip, ctx.Request.UserAgent())
 	if err != nil {
 		if err.Error() == "用户名或密码错误" {
 			utils.ErrorWithMsg(ctx, utils.PASSWORD_ERROR, err.Error())
@@ -145,6 +149,37 @@ func (c *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
+	if result.TwoFactorRequired {
+		utils.SuccessWithMsg(ctx, "请输入两步验证码", result)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "登录成功", result)
+}
+
+// LoginTwoFactor 登录第二步：提交两步验证码
+func (c *UserController) LoginTwoFactor(ctx *gin.Context) {
+	var req dto.TwoFactorLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.userService.LoginTwoFactor(&req, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		switch err.Error() {
+		case "登录已超时，请重新登录", "两步验证未启用":
+			utils.ErrorWithMsg(ctx, utils.TOKEN_INVALID, err.Error())
+		case "验证码错误", "验证失败次数过多，请15分钟后重试":
+			utils.ErrorWithMsg(ctx, utils.TWO_FACTOR_INVALID, err.Error())
+		case "用户已被封禁":
+			utils.ErrorWithMsg(ctx, utils.FORBIDDEN, err.Error())
+		default:
+			utils.ErrorWithMsg(ctx, utils.ERROR, err.Error())
+		}
+		return
+	}
+
 	utils.SuccessWithMsg(ctx, "登录成功", result)
 }
 
@@ -217,6 +252,63 @@ func (c *UserController) ChangePassword(ctx *gin.Context) {
 	utils.SuccessWithMsg(ctx, "修改密码成功", nil)
 }
 
+// SetPassword 通过邮件链接设置密码
+func (c *UserController) SetPassword(ctx *gin.Context) {
+	var req dto.SetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.userService.SetPasswordByToken(&req); err != nil {
+		if err.Error() == "链接无效或已过期" {
+			utils.ErrorWithMsg(ctx, utils.TOKEN_INVALID, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "设置密码失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "设置密码成功，请使用新密码登录", nil)
+}
+
+// RequestPasswordReset 申请重置密码（忘记密码）
+func (c *UserController) RequestPasswordReset(ctx *gin.Context) {
+	var req dto.RequestPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.userService.RequestPasswordReset(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.ERROR, "申请重置密码失败，请稍后重试")
+		return
+	}
+
+	// 不区分邮箱是否注册，统一返回
+	utils.SuccessWithMsg(ctx, "如果该邮箱已注册，重置链接已发送至邮箱，请在30分钟内完成重置", nil)
+}
+
+// ConfirmPasswordReset 确认重置密码
+func (c *UserController) ConfirmPasswordReset(ctx *gin.Context) {
+	var req dto.ConfirmPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.userService.ConfirmPasswordReset(&req); err != nil {
+		if err.Error() == "链接无效或已过期" {
+			utils.ErrorWithMsg(ctx, utils.TOKEN_INVALID, err.Error())
+			return
+		}
+		utils.ErrorWithMsg(ctx, utils.ERROR, "重置密码失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "密码已重置，所有设备已退出登录，请使用新密码登录", nil)
+}
+
 // GetUserByID 根据ID获取用户信息（管理员）
 func (c *UserController) GetUserByID(ctx *gin.Context) {
 	idStr := ctx.Param("id")
@@ -258,8 +350,7 @@ func (c *UserController) GetUserList(ctx *gin.Context) {
 
 // VerifyStudent 审核学生信息（院校负责人/管理员）
 func (c *UserController) VerifyStudent(ctx *gin.Context) {
-	verifierID, exists := ctx.Get("user_id")
-	if !exists {
+	if _, exists := ctx.Get("user_id"); !exists {
 		utils.Error(ctx, utils.UNAUTHORIZED)
 		return
 	}
@@ -277,20 +368,96 @@ func (c *UserController) VerifyStudent(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.userService.VerifyStudent(userID, verifierID.(int64), &req); err != nil {
-		if err.Error() == "用户不存在" {
-			utils.ErrorWithMsg(ctx, utils.USER_NOT_EXIST, err.Error())
-			return
-		}
-		if err.Error() == "该用户不是联合院校赛道，无需审核" {
-			utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
-			return
+	result, err := c.userService.VerifyStudent(operatorFromContext(ctx), userID, &req)
+	if err != nil {
+		c.handleVerifyError(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "审核成功", result)
+}
+
+// BatchVerifyStudents 批量审核学生（院校负责人/管理员）
+func (c *UserController) BatchVerifyStudents(ctx *gin.Context) {
+	var req dto.BatchVerifyStudentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	result, err := c.userService.BatchVerifyStudents(operatorFromContext(ctx), &req)
+	if err != nil {
+		c.handleVerifyError(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "批量审核完成", result)
+}
+
+// GetVerifyReasonTemplates 获取驳回理由模板（院校负责人/管理员）
+func (c *UserController) GetVerifyReasonTemplates(ctx *gin.Context) {
+	utils.Success(ctx, c.userService.GetVerifyReasonTemplates())
+}
+
+// GetPendingStudents 查看待审核学生列表（院校负责人）
+func (c *UserController) GetPendingStudents(ctx *gin.Context) {
+	var req dto.SchoolStudentListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+	req.VerifyStatus = "pending"
+
+	c.getSchoolStudents(ctx, &req)
+}
+
+// GetSchoolStudents 查看本校所有学生（院校负责人）
+func (c *UserController) GetSchoolStudents(ctx *gin.Context) {
+	var req dto.SchoolStudentListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "参数错误: "+err.Error())
+		return
+	}
+
+	c.getSchoolStudents(ctx, &req)
+}
+
+// getSchoolStudents 查询学校学生列表
+func (c *UserController) getSchoolStudents(ctx *gin.Context, req *dto.SchoolStudentListRequest) {
+	schoolID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, "无效的学校ID")
+		return
+	}
+
+	result, err := c.userService.GetSchoolStudents(schoolID, ctx.GetInt64("user_id"), ctx.GetString("role"), req)
+	if err != nil {
+		switch err.Error() {
+		case "学校不存在":
+			utils.Error(ctx, utils.SCHOOL_NOT_EXIST)
+		case "无权管理其他学校的学生":
+			utils.ErrorWithMsg(ctx, utils.PERMISSION_DENIED, err.Error())
+		default:
+			utils.ErrorWithMsg(ctx, utils.ERROR, "获取学生列表失败: "+err.Error())
 		}
-		utils.ErrorWithMsg(ctx, utils.ERROR, "审核失败: "+err.Error())
 		return
 	}
 
-	utils.SuccessWithMsg(ctx, "审核成功", nil)
+	utils.Success(ctx, result)
+}
+
+// handleVerifyError 处理审核错误
+func (c *UserController) handleVerifyError(ctx *gin.Context, err error) {
+	switch err.Error() {
+	case "用户不存在":
+		utils.ErrorWithMsg(ctx, utils.USER_NOT_EXIST, err.Error())
+	case "无权管理其他学校的学生":
+		utils.ErrorWithMsg(ctx, utils.PERMISSION_DENIED, err.Error())
+	case "该用户不是联合院校赛道，无需审核", "驳回时必须填写理由", "驳回理由模板不存在":
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+	default:
+		utils.ErrorWithMsg(ctx, utils.ERROR, "审核失败: "+err.Error())
+	}
 }
 
 // UpdateUserRole 更新用户角色（管理员）
@@ -308,8 +475,8 @@ func (c *UserController) UpdateUserRole(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.userService.UpdateUserRole(userID, req.Role); err != nil {
-		utils.ErrorWithMsg(ctx, utils.ERROR, "更新角色失败: "+err.Error())
+	if err := c.userService.UpdateUserRole(operatorFromContext(ctx), userID, req.Role); err != nil {
+		c.handleManageError(ctx, "更新角色失败: ", err)
 		return
 	}
 
@@ -331,10 +498,24 @@ func (c *UserController) UpdateUserStatus(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.userService.UpdateUserStatus(userID, req.Status); err != nil {
-		utils.ErrorWithMsg(ctx, utils.ERROR, "更新状态失败: "+err.Error())
+	if err := c.userService.UpdateUserStatus(operatorFromContext(ctx), userID, req.Status); err != nil {
+		c.handleManageError(ctx, "更新状态失败: ", err)
 		return
 	}
 
 	utils.SuccessWithMsg(ctx, "更新状态成功", nil)
 }
+
+// handleManageError 统一处理用户角色/状态管理错误
+func (c *UserController) handleManageError(ctx *gin.Context, prefix string, err error) {
+	switch err.Error() {
+	case "用户不存在":
+		utils.Error(ctx, utils.USER_NOT_EXIST)
+	case "仅超级管理员可以授予管理员权限", "仅超级管理员可以管理管理员账号":
+		utils.ErrorWithMsg(ctx, utils.PERMISSION_DENIED, err.Error())
+	case "不能修改自己的角色", "不能修改自己的状态":
+		utils.ErrorWithMsg(ctx, utils.INVALID_PARAMS, err.Error())
+	default:
+		utils.ErrorWithMsg(ctx, utils.ERROR, prefix+err.Error())
+	}
+}
@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+// AuditLogListRequest 审计日志查询请求，时间为 RFC3339 格式
+type AuditLogListRequest struct {
+	Page       int        `form:"page" binding:"omitempty,min=1"`
+	Limit      int        `form:"limit" binding:"omitempty,min=1,max=100"`
+	ActorID    int64      `form:"actor_id" binding:"omitempty,min=1"`
+	Action     string     `form:"action" binding:"omitempty,max=50"` // 支持前缀匹配，如 user. 匹配全部用户管理操作
+	TargetType string     `form:"target_type" binding:"omitempty,max=30"`
+	TargetID   string     `form:"target_id" binding:"omitempty,max=64"`
+	IP         string     `form:"ip" binding:"omitempty,max=50"`
+	StartTime  *time.Time `form:"start_time"`
+	EndTime    *time.Time `form:"end_time"`
+}
+
+// AuditChangeResponse 字段变更
+type AuditChangeResponse struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditLogResponse 审计日志响应
+type AuditLogResponse struct {
+	ID         int64                          `json:"id"`
+	ActorID    int64                          `json:"actor_id"`
+	ActorName  string                         `json:"actor_name"`
+	ActorRole  string                         `json:"actor_role"`
+	Action     string                         `json:"action"`
+	TargetType string                         `json:"target_type"`
+	TargetID   string                         `json:"target_id"`
+	Changes    map[string]AuditChangeResponse `json:"changes"`
+	IP         string                         `json:"ip"`
+	CreatedAt  time.Time                      `json:"created_at"`
+}
+
+// AuditLogListResponse 审计日志列表响应
+type AuditLogListResponse struct {
+	Total int                `json:"total"`
+	Page  int                `json:"page"`
+	Limit int                `json:"limit"`
+	List  []AuditLogResponse `json:"list"`
+}
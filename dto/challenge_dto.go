@@ -13,6 +13,15 @@ type CategoryRequest struct {
 	SortOrder   int     `json:"sort_order"`
 }
 
+// ServiceSpec 多容器题目中的单个服务
+type ServiceSpec struct {
+	Name    string            `json:"name" binding:"required,max=63"`                 // 服务名，同时作为私有网络内的别名
+	Image   string            `json:"image" binding:"required,max=255"`               // Docker 镜像
+	Env     map[string]string `json:"env"`                                            // 附加环境变量
+	Aliases []string          `json:"aliases" binding:"omitempty,max=10,dive,max=63"` // 私有网络内的其他别名
+	Ports   map[string]string `json:"ports"`                                          // 暴露到宿主机的端口，如 {"80": "tcp"}
+}
+
 // ChallengeRequest 题目创建/更新请求
 type ChallengeRequest struct {
 	ChallengeName string            `json:"challenge_name" binding:"required"`
@@ -25,10 +34,78 @@ type ChallengeRequest struct {
 	StaticFlag    *string           `json:"static_flag"`
 	DockerImage   *string           `json:"docker_image"`
 	DockerPorts   map[string]string `json:"docker_ports"`
+	Services      []ServiceSpec     `json:"services" binding:"omitempty,max=10,dive"` // 多容器服务，非空时以第一个服务为入口，忽略 docker_image/docker_ports
+	WarmPoolSize  int               `json:"warm_pool_size" binding:"min=0,max=50"`    // 预热容器数量，镜像需在运行时读取 /flag
 	Difficulty    string            `json:"difficulty" binding:"oneof=easy medium hard expert"`
 	InitialScore  int               `json:"initial_score" binding:"min=1"`
 	MinScore      int               `json:"min_score" binding:"min=0"`
 	DecayRatio    float64           `json:"decay_ratio" binding:"min=0.1,max=1.0"`
+	ReleaseAt     *time.Time        `json:"release_at"`                                                     // 定时放出时间，为空表示按 state 立即生效
+	ReleaseNotice bool              `json:"release_notice"`                                                 // 放出时是否自动发布公告
+	Tracks        []string          `json:"tracks" binding:"omitempty,dive,oneof=social freshman advanced"` // 限定赛道，为空表示全部赛道
+}
+
+// UpdateChallengeStateRequest 修改题目状态请求
+type UpdateChallengeStateRequest struct {
+	State string `json:"state" binding:"required,oneof=visible hidden"`
+}
+
+// ChallengeResponse 题目响应（选手/公开），不包含 Flag、容器镜像等服务端信息
+type ChallengeResponse struct {
+	ID            int64     `json:"id"`
+	ChallengeName string    `json:"challenge_name"`
+	Direction     string    `json:"direction"`
+	Author        string    `json:"author"`
+	Description   string    `json:"description"`
+	Hint          *string   `json:"hint"`
+	Mode          string    `json:"mode"`
+	Difficulty    string    `json:"difficulty"`
+	InitialScore  int       `json:"initial_score"`
+	CurrentScore  int       `json:"current_score"`
+	SolvedCount   int       `json:"solved_count"`
+	Tracks        []string  `json:"tracks"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AdminChallengeResponse 题目响应（管理端），包含 Flag、容器配置及放出设置
+type AdminChallengeResponse struct {
+	ChallengeResponse
+	State         string            `json:"state"`
+	StaticFlag    *string           `json:"static_flag"`
+	DockerImage   *string           `json:"docker_image"`
+	DockerPorts   map[string]string `json:"docker_ports"`
+	Services      []ServiceSpec     `json:"services"`
+	WarmPoolSize  int               `json:"warm_pool_size"`
+	MinScore      int               `json:"min_score"`
+	DecayRatio    float64           `json:"decay_ratio"`
+	UnlockScore   int               `json:"unlock_score"`
+	ReleaseAt     *time.Time        `json:"release_at"`
+	ReleaseNotice bool              `json:"release_notice"`
+	CreatedBy     int64             `json:"created_by"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// UpdateChallengeResponse 更新题目响应
+type UpdateChallengeResponse struct {
+	AdminChallengeResponse
+	Warnings []string `json:"warnings,omitempty"` // 需要管理员注意的提示，如修改已解出题目的 Flag
+}
+
+// ScheduleReleaseRequest 设置题目定时放出请求，release_at 为空表示取消定时
+type ScheduleReleaseRequest struct {
+	ReleaseAt     *time.Time `json:"release_at"`
+	ReleaseNotice bool       `json:"release_notice"`
+}
+
+// ChallengeReleaseResponse 待放出题目响应
+type ChallengeReleaseResponse struct {
+	ID            int64     `json:"id"`
+	ChallengeName string    `json:"challenge_name"`
+	Direction     string    `json:"direction"`
+	Difficulty    string    `json:"difficulty"`
+	State         string    `json:"state"`
+	ReleaseAt     time.Time `json:"release_at"`
+	ReleaseNotice bool      `json:"release_notice"`
 }
 
 // SubmitFlagRequest 提交 Flag 请求
@@ -54,7 +131,8 @@ type ChallengeListRequest struct {
 	Direction  string `form:"direction"`
 	Difficulty string `form:"difficulty"`
 	Search     string `form:"search"`
-	State      string `form:"state"` // 管理员用
+	State      string `form:"state"`                                                    // 管理员用
+	Track      string `form:"track" binding:"omitempty,oneof=social freshman advanced"` // 管理员用，筛选该赛道可见的题目
 }
 
 // LogListRequest 日志查询参数
@@ -67,3 +145,14 @@ type LogListRequest struct {
 	FlagResult  string `form:"flag_result"`
 	Search      string `form:"search"`
 }
+
+// ContainerQueueResponse 容器排队状态响应
+type ContainerQueueResponse struct {
+	ID          int64     `json:"id"`
+	ChallengeID int64     `json:"challenge_id"`
+	State       string    `json:"state"`        // waiting, starting, started, failed, cancelled
+	Position    int64     `json:"position"`     // 排队位置（从 1 开始），仅 waiting 状态有效
+	ContainerID int64     `json:"container_id"` // 启动成功后的容器记录ID
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,120 @@
+package dto
+
+import "time"
+
+// DashboardTrendRequest 得分趋势查询请求
+type DashboardTrendRequest struct {
+	Top int `form:"top" binding:"omitempty,min=1,max=50"` // 返回前几名队伍的趋势，默认 10
+}
+
+// DashboardStats 大屏统计数据
+type DashboardStats struct {
+	TotalTeams          int64 `json:"total_teams"`
+	ActiveTeams         int64 `json:"active_teams"` // 有提交记录的队伍
+	TotalSolves         int64 `json:"total_solves"`
+	TotalFlagsSubmitted int64 `json:"total_flags_submitted"`
+}
+
+// DashboardTopTeam 大屏前几名队伍简要信息
+type DashboardTopTeam struct {
+	Rank       int     `json:"rank"`
+	TeamID     int64   `json:"team_id"`
+	TeamName   string  `json:"team_name"`
+	Score      int     `json:"score"`
+	SchoolName *string `json:"school_name"`
+}
+
+// DashboardSolve 大屏最近解题记录
+type DashboardSolve struct {
+	TeamName      string    `json:"team_name"`
+	ChallengeName string    `json:"challenge_name"`
+	Time          time.Time `json:"time"`
+	IsFirstBlood  bool      `json:"is_first_blood"`
+}
+
+// DashboardCategoryProgress 大屏分类解题进度
+type DashboardCategoryProgress struct {
+	Direction   string `json:"direction"`
+	Name        string `json:"name"`
+	SolvedCount int64  `json:"solved_count"` // 该分类下解题总次数
+	TotalCount  int64  `json:"total_count"`  // 该分类下已放出题目数
+}
+
+// DashboardOverviewResponse 大屏聚合数据响应
+type DashboardOverviewResponse struct {
+	CompetitionStatus string                      `json:"competition_status"` // not_started, running, paused, ended
+	Countdown         int64                       `json:"countdown"`          // 未开始时为距离开始秒数，进行中为距离结束秒数，未配置时间为 0
+	StartTime         *time.Time                  `json:"start_time"`
+	EndTime           *time.Time                  `json:"end_time"`
+	Stats             DashboardStats              `json:"stats"`
+	TopTeams          []DashboardTopTeam          `json:"top_teams"`
+	RecentSolves      []DashboardSolve            `json:"recent_solves"`
+	CategoryProgress  []DashboardCategoryProgress `json:"category_progress"`
+	GeneratedAt       time.Time                   `json:"generated_at"`
+}
+
+// DashboardTrendSeries 单个队伍的得分曲线
+type DashboardTrendSeries struct {
+	TeamID   int64  `json:"team_id"`
+	TeamName string `json:"team_name"`
+	Scores   []int  `json:"scores"`
+}
+
+// DashboardTrendResponse 得分趋势响应
+type DashboardTrendResponse struct {
+	Times       []time.Time            `json:"times"` // X 轴采样时间点
+	Series      []DashboardTrendSeries `json:"series"`
+	GeneratedAt time.Time              `json:"generated_at"`
+}
+
+// SolveMatrixRequest 解题矩阵查询请求
+type SolveMatrixRequest struct {
+	Page  int    `form:"page" binding:"omitempty,min=1"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Track string `form:"track" binding:"omitempty,oneof=social freshman advanced"` // 为空时为总榜（仅不限赛道的题目）
+}
+
+// SolveMatrixChallenge 解题矩阵中的题目（列）
+type SolveMatrixChallenge struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Score      int    `json:"score"`
+	SolveCount int64  `json:"solve_count"`
+}
+
+// SolveMatrixCategory 解题矩阵中的分类及其统计
+type SolveMatrixCategory struct {
+	Direction      string                 `json:"direction"`
+	Name           string                 `json:"name"`
+	ChallengeCount int                    `json:"challenge_count"`
+	SolveCount     int64                  `json:"solve_count"`
+	AverageSolves  float64                `json:"average_solves"` // 平均每题解出次数
+	Challenges     []SolveMatrixChallenge `json:"challenges"`
+}
+
+// SolveMatrixCell 解题矩阵单元格，仅包含已解出的题目
+type SolveMatrixCell struct {
+	SolvingTime time.Time `json:"solving_time"`
+	Blood       int       `json:"blood,omitempty"` // 1/2/3 分别表示一/二/三血
+}
+
+// SolveMatrixTeam 解题矩阵中的队伍（行）
+type SolveMatrixTeam struct {
+	Rank       int                       `json:"rank"`
+	TeamID     int64                     `json:"team_id"`
+	TeamName   string                    `json:"team_name"`
+	Score      int                       `json:"score"`
+	SolveCount int                       `json:"solve_count"`
+	Solves     map[int64]SolveMatrixCell `json:"solves"` // 以题目 ID 为键
+}
+
+// SolveMatrixResponse 解题矩阵响应
+type SolveMatrixResponse struct {
+	Track       string                `json:"track"`
+	Total       int64                 `json:"total"` // 队伍总数
+	Page        int                   `json:"page"`
+	Limit       int                   `json:"limit"`
+	Categories  []SolveMatrixCategory `json:"categories"`
+	Teams       []SolveMatrixTeam     `json:"teams"`
+	GeneratedAt time.Time             `json:"generated_at"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+// HintRequest 创建/修改题目提示请求（管理员）
+type HintRequest struct {
+	Content   string     `json:"content" binding:"required"`
+	Cost      int        `json:"cost" binding:"min=0"`
+	ReleaseAt *time.Time `json:"release_at"`
+	SortOrder int        `json:"sort_order"`
+}
+
+// HintResponse 选手侧提示响应
+type HintResponse struct {
+	ID          int64      `json:"id"`
+	ChallengeID int64      `json:"challenge_id"`
+	Cost        int        `json:"cost"`
+	ReleaseAt   *time.Time `json:"release_at"`
+	SortOrder   int        `json:"sort_order"`
+	Unlocked    bool       `json:"unlocked"`
+	Content     *string    `json:"content"` // 未解锁时为 null
+}
+
+// HintUnlockInfo 提示解锁记录
+type HintUnlockInfo struct {
+	TeamID     int64     `json:"team_id"`
+	TeamName   string    `json:"team_name"`
+	UserID     int64     `json:"user_id"`
+	Cost       int       `json:"cost"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+}
+
+// AdminHintResponse 管理员侧提示响应（包含解锁记录）
+type AdminHintResponse struct {
+	ID          int64            `json:"id"`
+	ChallengeID int64            `json:"challenge_id"`
+	Content     string           `json:"content"`
+	Cost        int              `json:"cost"`
+	ReleaseAt   *time.Time       `json:"release_at"`
+	SortOrder   int              `json:"sort_order"`
+	UnlockCount int              `json:"unlock_count"`
+	Unlocks     []HintUnlockInfo `json:"unlocks"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// NoticeRequest 发布/修改公告请求（管理员）
+type NoticeRequest struct {
+	Title       string `json:"title" binding:"required,max=255"`
+	Content     string `json:"content" binding:"required"`
+	IsTop       bool   `json:"is_top"`
+	Status      string `json:"status" binding:"omitempty,oneof=published draft archived"`
+	ChallengeID *int64 `json:"challenge_id" binding:"omitempty,min=1"`
+}
+
+// UpdateNoticeTopRequest 置顶/取消置顶请求（管理员）
+type UpdateNoticeTopRequest struct {
+	IsTop *bool `json:"is_top" binding:"required"`
+}
+
+// NoticeListRequest 公告列表查询请求
+type NoticeListRequest struct {
+	Page        int    `form:"page" binding:"omitempty,min=1"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Search      string `form:"search" binding:"omitempty,max=50"`
+	ChallengeID *int64 `form:"challenge_id"`
+	Status      string `form:"status" binding:"omitempty,oneof=published draft archived"` // 管理员用
+}
+
+// NoticeResponse 公告响应
+type NoticeResponse struct {
+	ID            int64     `json:"id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content,omitempty"`
+	IsTop         bool      `json:"is_top"`
+	Status        string    `json:"status,omitempty"`
+	ChallengeID   *int64    `json:"challenge_id"`
+	ChallengeName *string   `json:"challenge_name,omitempty"`
+	CreatedByName string    `json:"created_by_name,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NoticeListResponse 公告列表响应
+type NoticeListResponse struct {
+	Total int              `json:"total"`
+	Page  int              `json:"page"`
+	Limit int              `json:"limit"`
+	List  []NoticeResponse `json:"list"`
+}
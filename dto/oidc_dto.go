@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// OIDCProviderResponse 可用的统一身份认证提供方
+type OIDCProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OIDCAuthorizeResponse 发起认证响应
+// 前端需保存 StateToken，跳转 AuthURL 认证完成后连同回调参数一起提交
+type OIDCAuthorizeResponse struct {
+	AuthURL    string `json:"auth_url"`
+	StateToken string `json:"state_token"`
+}
+
+// OIDCCallbackRequest 认证回调请求（前端回调页收到 code/state 后提交）
+type OIDCCallbackRequest struct {
+	Code       string `json:"code" binding:"required"`
+	State      string `json:"state" binding:"required"`
+	StateToken string `json:"state_token" binding:"required"`
+}
+
+// UserIdentityResponse 已绑定的外部身份
+type UserIdentityResponse struct {
+	ID          int64      `json:"id"`
+	Provider    string     `json:"provider"`
+	DisplayName string     `json:"display_name"`
+	Email       *string    `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+// PermissionResponse 权限定义
+type PermissionResponse struct {
+	Permission  string `json:"permission"`
+	Description string `json:"description"`
+}
+
+// RolePermissionItem 角色及其权限
+type RolePermissionItem struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+	Editable    bool     `json:"editable"` // 超级管理员固定拥有全部权限，不可修改
+}
+
+// RolePermissionsResponse 角色权限总览
+type RolePermissionsResponse struct {
+	Permissions []PermissionResponse `json:"permissions"`
+	Roles       []RolePermissionItem `json:"roles"`
+}
+
+// SetRolePermissionsRequest 设置角色权限请求（覆盖）
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"max=50"`
+}
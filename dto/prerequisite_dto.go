@@ -0,0 +1,30 @@
+package dto
+
+// PrerequisiteRequest 设置题目解锁条件请求（管理员）
+// 需同时满足：解出全部前置题目，且团队分数不低于 UnlockScore
+type PrerequisiteRequest struct {
+	RequiredChallengeIDs []int64 `json:"required_challenge_ids"`
+	UnlockScore          int     `json:"unlock_score" binding:"min=0"`
+}
+
+// PrerequisiteNode 依赖图节点
+type PrerequisiteNode struct {
+	ID                   int64   `json:"id"`
+	ChallengeName        string  `json:"challenge_name"`
+	Direction            string  `json:"direction"`
+	State                string  `json:"state"`
+	UnlockScore          int     `json:"unlock_score"`
+	RequiredChallengeIDs []int64 `json:"required_challenge_ids"`
+}
+
+// PrerequisiteEdge 依赖图边（RequiredChallengeID -> ChallengeID）
+type PrerequisiteEdge struct {
+	ChallengeID         int64 `json:"challenge_id"`
+	RequiredChallengeID int64 `json:"required_challenge_id"`
+}
+
+// PrerequisiteGraphResponse 题目依赖图响应
+type PrerequisiteGraphResponse struct {
+	Nodes []PrerequisiteNode `json:"nodes"`
+	Edges []PrerequisiteEdge `json:"edges"`
+}
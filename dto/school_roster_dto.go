@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// RosterEntry 名单条目
+type RosterEntry struct {
+	StudentNumber string `json:"student_number" binding:"required,min=5,max=20,alphanum"`
+	UserName      string `json:"user_name" binding:"required,min=2,max=20"`
+}
+
+// AddRosterRequest 批量添加学校名单请求（管理员）
+type AddRosterRequest struct {
+	Entries []RosterEntry `json:"entries" binding:"required,min=1,max=5000,dive"`
+}
+
+// AddRosterResponse 批量添加学校名单响应
+type AddRosterResponse struct {
+	AddedCount   int      `json:"added_count"`
+	SkippedCount int      `json:"skipped_count"`
+	Skipped      []string `json:"skipped"` // 已存在而跳过的学号
+}
+
+// RosterListRequest 学校名单查询请求
+type RosterListRequest struct {
+	Page    int    `form:"page" binding:"omitempty,min=1"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Search  string `form:"search" binding:"omitempty,max=50"` // 模糊搜索姓名、学号
+	Matched *bool  `form:"matched"`                           // 是否已匹配注册用户
+}
+
+// RosterResponse 学校名单条目响应
+type RosterResponse struct {
+	ID            int64      `json:"id"`
+	SchoolID      int64      `json:"school_id"`
+	StudentNumber string     `json:"student_number"`
+	UserName      string     `json:"user_name"`
+	MatchedUserID *int64     `json:"matched_user_id"`
+	MatchedAt     *time.Time `json:"matched_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// RosterListResponse 学校名单列表响应
+type RosterListResponse struct {
+	Total int              `json:"total"`
+	Page  int              `json:"page"`
+	Limit int              `json:"limit"`
+	List  []RosterResponse `json:"list"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+// StudentImportRequest 批量导入学生请求（multipart 表单，文件字段为 file）
+type StudentImportRequest struct {
+	DryRun    bool `form:"dry_run"`    // 仅校验并返回结果，不写入数据库
+	SendEmail bool `form:"send_email"` // 导入成功后发送设置密码邮件
+}
+
+// StudentImportRow 单行导入结果
+type StudentImportRow struct {
+	Row           int      `json:"row"` // 文件中的行号（含表头）
+	Username      string   `json:"username"`
+	Email         string   `json:"email"`
+	UserName      string   `json:"user_name"`
+	StudentNumber string   `json:"student_number"`
+	Status        string   `json:"status"` // valid(仅校验通过), created, invalid
+	UserID        *int64   `json:"user_id,omitempty"`
+	EmailSent     bool     `json:"email_sent"`
+	Errors        []string `json:"errors"`
+}
+
+// StudentImportResponse 批量导入学生响应
+type StudentImportResponse struct {
+	DryRun       bool               `json:"dry_run"`
+	TotalRows    int                `json:"total_rows"`
+	ValidRows    int                `json:"valid_rows"`
+	InvalidRows  int                `json:"invalid_rows"`
+	CreatedCount int                `json:"created_count"`
+	EmailSent    int                `json:"email_sent"`
+	Rows         []StudentImportRow `json:"rows"`
+}
+
+// SetPasswordRequest 通过邮件链接设置密码请求
+type SetPasswordRequest struct {
+	Token       string `json:"token" binding:"required,len=64,hexadecimal"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=50,containsany=!@#$%^&*"`
+}
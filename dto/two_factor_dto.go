@@ -0,0 +1,36 @@
+package dto
+
+// TwoFactorLoginRequest 登录第二步：提交两步验证码
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string `json:"two_factor_token" binding:"required"`
+	Code           string `json:"code" binding:"required,min=6,max=20"` // 6位动态验证码或恢复码
+}
+
+// TwoFactorCodeRequest 提交动态验证码（启用、重新生成恢复码）
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// DisableTwoFactorRequest 关闭两步验证请求
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required,min=1,max=50"`
+	Code     string `json:"code" binding:"required,min=6,max=20"` // 6位动态验证码或恢复码
+}
+
+// TwoFactorSetupResponse 两步验证绑定信息
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`           // Base32 密钥，供手动输入
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// 链接，前端据此生成二维码
+}
+
+// TwoFactorStatusResponse 两步验证状态
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	SessionVerified        bool `json:"session_verified"` // 当前会话是否通过两步验证
+}
+
+// RecoveryCodesResponse 恢复码（仅在生成时返回一次明文）
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
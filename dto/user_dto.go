@@ -41,9 +41,39 @@ type LoginRequest struct {
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token     string       `json:"token"`
-	ExpiresAt int64        `json:"expires_at"`
-	UserInfo  UserResponse `json:"user_info"`
+	Token            string       `json:"token"`
+	ExpiresAt        int64        `json:"expires_at"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresAt int64        `json:"refresh_expires_at"`
+	UserInfo         UserResponse `json:"user_info"`
+
+	// 已启用两步验证时仅返回以下字段，需携带 TwoFactorToken 调用 /login/2fa 完成登录
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
+}
+
+// RefreshTokenRequest 刷新 Token 请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required,len=64,hexadecimal"`
+}
+
+// TokenPairResponse Token 刷新响应（Refresh Token 每次刷新后轮换）
+type TokenPairResponse struct {
+	Token            string `json:"token"`
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`
+}
+
+// SessionResponse 登录会话响应
+type SessionResponse struct {
+	ID         int64     `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"` // 是否为当前请求所用会话
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
 }
 
 // UserResponse 用户响应
@@ -78,10 +108,93 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=8,max=50,containsany=!@#$%^&*"`
 }
 
+// RequestPasswordResetRequest 申请重置密码请求
+type RequestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+// ConfirmPasswordResetRequest 确认重置密码请求
+type ConfirmPasswordResetRequest struct {
+	Token       string `json:"token" binding:"required,len=64,hexadecimal"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=50,containsany=!@#$%^&*"`
+}
+
 // VerifyStudentRequest 审核学生信息请求（院校负责人）
 type VerifyStudentRequest struct {
-	VerifyStatus string  `json:"verify_status" binding:"required,oneof=approved rejected"`
-	VerifyReason *string `json:"verify_reason"`
+	VerifyStatus   string  `json:"verify_status" binding:"required,oneof=approved rejected"`
+	VerifyReason   *string `json:"verify_reason" binding:"omitempty,max=500"`
+	ReasonTemplate string  `json:"reason_template"` // 驳回理由模板，与 verify_reason 同时提供时拼接
+}
+
+// BatchVerifyStudentRequest 批量审核学生请求（院校负责人）
+type BatchVerifyStudentRequest struct {
+	UserIDs        []int64 `json:"user_ids" binding:"required,min=1,max=100"`
+	VerifyStatus   string  `json:"verify_status" binding:"required,oneof=approved rejected"`
+	VerifyReason   *string `json:"verify_reason" binding:"omitempty,max=500"`
+	ReasonTemplate string  `json:"reason_template"`
+}
+
+// StudentReviewResponse 审核结果响应
+type StudentReviewResponse struct {
+	ID                int64      `json:"id"`
+	Username          string     `json:"username"`
+	UserName          *string    `json:"user_name"`
+	VerifyStatus      string     `json:"verify_status"`
+	VerifyReason      *string    `json:"verify_reason"`
+	VerifiedBy        *int64     `json:"verified_by"`
+	VerifiedAt        *time.Time `json:"verified_at"`
+	RegisterFailCount int        `json:"register_fail_count"`
+}
+
+// BatchVerifyFailure 批量审核失败项
+type BatchVerifyFailure struct {
+	UserID int64  `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// BatchVerifyStudentResponse 批量审核结果响应
+type BatchVerifyStudentResponse struct {
+	SuccessIDs []int64              `json:"success_ids"`
+	Failed     []BatchVerifyFailure `json:"failed"`
+}
+
+// ReasonTemplateResponse 驳回理由模板
+type ReasonTemplateResponse struct {
+	Key     string `json:"key"`
+	Content string `json:"content"`
+}
+
+// SchoolStudentListRequest 本校学生列表查询请求（院校负责人）
+type SchoolStudentListRequest struct {
+	Page         int    `form:"page" binding:"omitempty,min=1"`
+	Limit        int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	VerifyStatus string `form:"verify_status" binding:"omitempty,oneof=pending approved rejected"`
+	Search       string `form:"search" binding:"omitempty,max=50"` // 模糊搜索姓名、学号
+}
+
+// SchoolStudentResponse 本校学生信息响应
+type SchoolStudentResponse struct {
+	ID                int64      `json:"id"`
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	UserName          *string    `json:"user_name"`
+	StudentNumber     *string    `json:"student_number"`
+	SchoolGrade       *string    `json:"school_grade"`
+	StudentNature     *string    `json:"student_nature"`
+	VerifyStatus      string     `json:"verify_status"`
+	VerifyReason      *string    `json:"verify_reason"`
+	VerifiedAt        *time.Time `json:"verified_at"`
+	RegisterFailCount int        `json:"register_fail_count"`
+	Status            string     `json:"status"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// SchoolStudentListResponse 本校学生列表响应
+type SchoolStudentListResponse struct {
+	Total int                     `json:"total"`
+	Page  int                     `json:"page"`
+	Limit int                     `json:"limit"`
+	List  []SchoolStudentResponse `json:"list"`
 }
 
 // UserListRequest 用户列表查询请求
@@ -89,7 +202,7 @@ type UserListRequest struct {
 	Page         int    `form:"page" binding:"omitempty,min=1"`
 	Limit        int    `form:"limit" binding:"omitempty,min=1,max=100"`
 	Search       string `form:"search"`
-	Role         string `form:"role" binding:"omitempty,oneof=user school_admin admin super_admin"`
+	Role         string `form:"role" binding:"omitempty,oneof=user challenge_author school_admin admin super_admin"`
 	Track        string `form:"track" binding:"omitempty,oneof=social school"`
 	SchoolID     *int64 `form:"school_id"`
 	VerifyStatus string `form:"verify_status" binding:"omitempty,oneof=pending approved rejected"`
@@ -108,7 +221,7 @@ type UserListResponse struct {
 
 // UpdateUserRoleRequest 更新用户角色请求（管理员）
 type UpdateUserRoleRequest struct {
-	Role string `json:"role" binding:"required,oneof=user school_admin admin super_admin"`
+	Role string `json:"role" binding:"required,oneof=user challenge_author school_admin admin super_admin"`
 }
 
 // UpdateUserStatusRequest 更新用户状态请求（管理员）
@@ -5,6 +5,8 @@ import (
 	"isctf/cmd"
 	"isctf/config"
 	"isctf/routes"
+	"isctf/services"
+	"isctf/utils"
 	"os"
 )
 
@@ -14,7 +16,9 @@ func main() {
 	fmt.Println("========================================")
 
 	config.InitConfig()
+	utils.InitJWTKeys()
 	config.ConnectDatabase()
+	config.ConnectCache()
 
 	// 检查是否需要初始化管理员账户
 	if len(os.Args) > 1 {
@@ -41,6 +45,18 @@ func main() {
 	// 自动检查并创建默认管理员
 	cmd.InitDefaultAdmin()
 
+	// 启动题目定时放出调度器
+	services.StartReleaseScheduler()
+
+	// 启动容器状态同步任务
+	services.StartContainerReconciler()
+
+	// 启动容器排队调度任务
+	services.StartContainerQueue()
+
+	// 启动容器预热池补充任务
+	services.StartWarmPool()
+
 	r := routes.SetupRouter()
 
 	serverAddr := ":" + config.AppConfig.Server.Port
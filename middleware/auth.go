@@ -5,6 +5,7 @@ import (
 	"isctf/models"
 	"isctf/utils"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -44,87 +45,70 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 重置密码后此前签发的 Token 全部失效；会话被吊销（退出登录/远程下线）后 Token 同样失效
+		if claims.IssuedAt == nil || user.IsTokenRevoked(claims.IssuedAt.Time) || !isSessionActive(claims) {
+			utils.ErrorWithMsg(c, utils.TOKEN_INVALID, "登录状态已失效，请重新登录")
+			c.Abort()
+			return
+		}
+
 		// 设置用户信息到上下文
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("two_factor", claims.TwoFactor)
 
 		c.Next()
 	}
 }
 
-// AdminMiddleware 管理员权限中间件
-func AdminMiddleware() gin.HandlerFunc {
+// OptionalAuthMiddleware 可选认证中间件
+// 携带有效 Token 时设置用户信息到上下文，未携带或无效时按游客处理，不中断请求
+func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 确保用户已通过认证
-		userID, exists := c.Get("user_id")
-		if !exists {
-			utils.Error(c, utils.UNAUTHORIZED)
-			c.Abort()
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.Next()
 			return
 		}
 
-		// 获取用户角色
-		role, exists := c.Get("role")
-		if !exists {
-			utils.Error(c, utils.PERMISSION_DENIED)
-			c.Abort()
+		claims, err := utils.ParseToken(parts[1])
+		if err != nil {
+			c.Next()
 			return
 		}
 
-		// 验证管理员权限
-		if role != "admin" && role != "super_admin" {
-			utils.ErrorWithMsg(c, utils.PERMISSION_DENIED, "需要管理员权限")
-			c.Abort()
+		var user models.User
+		if err := config.DB.Where("id = ? AND status = ?", claims.UserID, "active").First(&user).Error; err != nil {
+			c.Next()
 			return
 		}
-
-		// 再次验证数据库中的用户角色
-		var user models.User
-		if err := config.DB.Where("id = ? AND role IN ?", userID, []string{"admin", "super_admin"}).First(&user).Error; err != nil {
-			utils.ErrorWithMsg(c, utils.PERMISSION_DENIED, "用户权限验证失败")
-			c.Abort()
+		if claims.IssuedAt == nil || user.IsTokenRevoked(claims.IssuedAt.Time) || !isSessionActive(claims) {
+			c.Next()
 			return
 		}
 
+		c.Set("user_id", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("two_factor", claims.TwoFactor)
+
 		c.Next()
 	}
 }
 
-// SchoolAdminMiddleware 学校管理员权限中间件
-func SchoolAdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 确保用户已通过认证
-		userID, exists := c.Get("user_id")
-		if !exists {
-			utils.Error(c, utils.UNAUTHORIZED)
-			c.Abort()
-			return
-		}
-
-		// 获取用户角色
-		role, exists := c.Get("role")
-		if !exists {
-			utils.Error(c, utils.PERMISSION_DENIED)
-			c.Abort()
-			return
-		}
-
-		// 验证学校管理员权限（admin, school_admin, super_admin）
-		if role != "admin" && role != "school_admin" && role != "super_admin" {
-			utils.ErrorWithMsg(c, utils.PERMISSION_DENIED, "需要学校管理员权限")
-			c.Abort()
-			return
-		}
-
-		// 再次验证数据库中的用户角色
-		var user models.User
-		if err := config.DB.Where("id = ? AND role IN ?", userID, []string{"admin", "school_admin", "super_admin"}).First(&user).Error; err != nil {
-			utils.ErrorWithMsg(c, utils.PERMISSION_DENIED, "用户权限验证失败")
-			c.Abort()
-			return
-		}
-
-		c.Next()
+// isSessionActive 检查 Token 所属会话是否未吊销且未过期
+func isSessionActive(claims *utils.Claims) bool {
+	if claims.SessionID == 0 {
+		return false
+	}
+	var count int64
+	if err := config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", claims.SessionID, claims.UserID, time.Now()).
+		Count(&count).Error; err != nil {
+		return false
 	}
+	return count > 0
 }
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"fmt"
+	"isctf/config"
+	"isctf/services"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContainerProxyMiddleware 容器子域名反向代理中间件
+// 请求 Host 为 <token>.<CONTAINER_PROXY_DOMAIN> 时转发到对应容器，其余请求交给后续路由处理
+func ContainerProxyMiddleware() gin.HandlerFunc {
+	proxyService := services.NewContainerProxyService()
+	return func(c *gin.Context) {
+		target, ok, err := proxyService.ResolveHost(c.Request.Host)
+		if !ok {
+			c.Next()
+			return
+		}
+		defer c.Abort()
+
+		if err != nil {
+			c.String(http.StatusBadGateway, "容器地址解析失败")
+			return
+		}
+		if target == nil {
+			c.String(http.StatusNotFound, "容器不存在或已停止")
+			return
+		}
+
+		if config.AppConfig.Container.ProxyRequireAuth {
+			// 携带票据：兑换为会话 Cookie 后重定向到去掉票据的地址
+			if ticket := c.Query(services.ProxyTicketParam); ticket != "" {
+				session, err := proxyService.RedeemTicket(ticket, target)
+				if err != nil {
+					c.String(http.StatusForbidden, err.Error())
+					return
+				}
+				http.SetCookie(c.Writer, &http.Cookie{
+					Name:     services.ProxySessionCookie,
+					Value:    session,
+					Path:     "/",
+					Expires:  target.EndTime,
+					HttpOnly: true,
+					Secure:   config.AppConfig.Container.ProxyScheme == "https",
+					SameSite: http.SameSiteLaxMode,
+				})
+				query := c.Request.URL.Query()
+				query.Del(services.ProxyTicketParam)
+				redirect := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+				c.Redirect(http.StatusFound, redirect.String())
+				return
+			}
+
+			session, err := c.Cookie(services.ProxySessionCookie)
+			if err != nil || !proxyService.CheckSession(session, target) {
+				c.String(http.StatusForbidden, "无权访问该容器，请从平台重新打开题目环境")
+				return
+			}
+			stripCookie(c.Request, services.ProxySessionCookie)
+		}
+
+		proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: target.Addr})
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			fmt.Printf("容器 %d 反向代理失败: %v\n", target.ContainerID, err)
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		proxy.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// stripCookie 从请求中移除平台 Cookie，避免转发给题目容器
+func stripCookie(r *http.Request, name string) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	parts := make([]string, 0, len(cookies))
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			parts = append(parts, cookie.String())
+		}
+	}
+	if len(parts) > 0 {
+		r.Header.Set("Cookie", strings.Join(parts, "; "))
+	}
+}
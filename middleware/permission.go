@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"isctf/config"
+	"isctf/models"
+	"isctf/services"
+	"isctf/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 权限中间件，拥有任一指定权限即可访问
+// 以数据库中的当前角色为准，并将上下文中的 role 更新为该角色
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	permissionService := services.NewPermissionService()
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.Error(c, utils.UNAUTHORIZED)
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := config.DB.Select("id", "role").Where("id = ? AND status = ?", userID, "active").First(&user).Error; err != nil {
+			utils.ErrorWithMsg(c, utils.PERMISSION_DENIED, "用户权限验证失败")
+			c.Abort()
+			return
+		}
+
+		allowed, err := permissionService.HasPermission(user.Role, permissions...)
+		if err != nil {
+			utils.ErrorWithMsg(c, utils.ERROR, "权限验证失败")
+			c.Abort()
+			return
+		}
+		if !allowed {
+			utils.ErrorWithMsg(c, utils.PERMISSION_DENIED, "权限不足")
+			c.Abort()
+			return
+		}
+
+		// 开启后管理员接口仅允许通过两步验证登录的会话访问
+		if config.AppConfig.Security.AdminRequire2FA && user.IsAdmin() && !c.GetBool("two_factor") {
+			utils.ErrorWithMsg(c, utils.TWO_FACTOR_REQUIRED, "管理员接口需要启用两步验证并重新登录")
+			c.Abort()
+			return
+		}
+
+		c.Set("role", user.Role)
+		c.Next()
+	}
+}
+
+// RequireChallengeOwnership 题目归属中间件，需放在 RequirePermission 之后
+// 仅拥有 challenge.write.own 权限的角色（出题人）只能操作自己创建的题目，路径参数 :id 为题目ID
+func RequireChallengeOwnership() gin.HandlerFunc {
+	permissionService := services.NewPermissionService()
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		if idStr == "" {
+			c.Next()
+			return
+		}
+
+		canWriteAll, err := permissionService.HasPermission(c.GetString("role"), models.PermChallengeWrite)
+		if err != nil {
+			utils.ErrorWithMsg(c, utils.ERROR, "权限验证失败")
+			c.Abort()
+			return
+		}
+		if canWriteAll {
+			c.Next()
+			return
+		}
+
+		challengeID, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			utils.ErrorWithMsg(c, utils.INVALID_PARAMS, "无效的题目ID")
+			c.Abort()
+			return
+		}
+
+		var count int64
+		if err := config.DB.Model(&models.Challenge{}).
+			Where("id = ? AND created_by = ? AND deleted_at IS NULL", challengeID, c.GetInt64("user_id")).
+			Count(&count).Error; err != nil || count == 0 {
+			utils.ErrorWithMsg(c, utils.PERMISSION_DENIED, "只能管理自己创建的题目")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// 审计操作类型
+const (
+	AuditUserRole           = "user.role"
+	AuditUserStatus         = "user.status"
+	AuditStudentVerify      = "student.verify"
+	AuditStudentImport      = "student.import"
+	AuditTeamStatus         = "team.status"
+	AuditChallengeCreate    = "challenge.create"
+	AuditChallengeUpdate    = "challenge.update"
+	AuditChallengeState     = "challenge.state"
+	AuditChallengeDelete    = "challenge.delete"
+	AuditChallengeRelease   = "challenge.release"
+	AuditChallengePrereq    = "challenge.prerequisite"
+	AuditHintCreate         = "hint.create"
+	AuditHintUpdate         = "hint.update"
+	AuditHintDelete         = "hint.delete"
+	AuditNoticeCreate       = "notice.create"
+	AuditNoticeUpdate       = "notice.update"
+	AuditNoticeTop          = "notice.top"
+	AuditNoticeDelete       = "notice.delete"
+	AuditSchoolCreate       = "school.create"
+	AuditSchoolUpdate       = "school.update"
+	AuditSchoolStatus       = "school.status"
+	AuditSchoolDelete       = "school.delete"
+	AuditRosterAdd          = "roster.add"
+	AuditRosterDelete       = "roster.delete"
+	AuditRolePermissionsSet = "role.permissions"
+)
+
+// AuditChange 单个字段的变更前后值
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditChanges 字段变更集合，键为字段名
+type AuditChanges map[string]AuditChange
+
+// Value 实现driver.Valuer接口
+func (ac AuditChanges) Value() (driver.Value, error) {
+	return json.Marshal(ac)
+}
+
+// Scan 实现sql.Scanner接口
+func (ac *AuditChanges) Scan(value interface{}) error {
+	if value == nil {
+		*ac = make(AuditChanges)
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, ac)
+	case string:
+		return json.Unmarshal([]byte(v), ac)
+	}
+	return nil
+}
+
+// AuditLog 管理操作审计日志（只追加，不修改不删除）
+type AuditLog struct {
+	ID         int64        `gorm:"primaryKey;autoIncrement" json:"id"`
+	ActorID    int64        `gorm:"not null;index:idx_actor_id" json:"actor_id"`
+	ActorName  string       `gorm:"type:varchar(50);not null" json:"actor_name"`
+	ActorRole  string       `gorm:"type:varchar(20);not null" json:"actor_role"`
+	Action     string       `gorm:"type:varchar(50);not null;index:idx_action" json:"action"`
+	TargetType string       `gorm:"type:varchar(30);not null;index:idx_target,priority:1" json:"target_type"`
+	TargetID   string       `gorm:"type:varchar(64);not null;index:idx_target,priority:2" json:"target_id"`
+	Changes    AuditChanges `gorm:"type:json" json:"changes"`
+	IP         string       `gorm:"type:varchar(50);not null" json:"ip"`
+	CreatedAt  time.Time    `gorm:"autoCreateTime;index:idx_created_at" json:"created_at"`
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "dalictf_audit_log"
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -32,6 +33,73 @@ func (dp *DockerPorts) Scan(value interface{}) error {
 	return nil
 }
 
+// ServiceSpec 多容器题目中的单个服务
+type ServiceSpec struct {
+	Name    string            `json:"name"`              // 服务名，同时作为私有网络内的别名
+	Image   string            `json:"image"`             // Docker 镜像
+	Env     map[string]string `json:"env,omitempty"`     // 附加环境变量
+	Aliases []string          `json:"aliases,omitempty"` // 私有网络内的其他别名
+	Ports   DockerPorts       `json:"ports,omitempty"`   // 暴露到宿主机的端口，未列出的端口仅在私有网络内可访问
+}
+
+// ServiceSpecs 多容器题目的服务列表，第一个服务为入口服务（写入 Flag 文件）
+type ServiceSpecs []ServiceSpec
+
+// Value 实现driver.Valuer接口
+func (cs ServiceSpecs) Value() (driver.Value, error) {
+	if len(cs) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(cs)
+}
+
+// Scan 实现sql.Scanner接口
+func (cs *ServiceSpecs) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, cs)
+	case string:
+		return json.Unmarshal([]byte(v), cs)
+	}
+	*cs = nil
+	return nil
+}
+
+// ChallengeTracks 题目限定赛道集合（对应 MySQL SET 类型，为空表示不限赛道）
+type ChallengeTracks []string
+
+// Value 实现driver.Valuer接口
+func (ct ChallengeTracks) Value() (driver.Value, error) {
+	return strings.Join(ct, ","), nil
+}
+
+// Scan 实现sql.Scanner接口
+func (ct *ChallengeTracks) Scan(value interface{}) error {
+	var v string
+	switch val := value.(type) {
+	case []byte:
+		v = string(val)
+	case string:
+		v = val
+	}
+	if v == "" {
+		*ct = ChallengeTracks{}
+		return nil
+	}
+	*ct = strings.Split(v, ",")
+	return nil
+}
+
+// Contains 检查赛道是否在集合中
+func (ct ChallengeTracks) Contains(track string) bool {
+	for _, t := range ct {
+		if t == track {
+			return true
+		}
+	}
+	return false
+}
+
 // Challenge 题目模型
 type Challenge struct {
 	ID            int64        `json:"id" gorm:"primaryKey;autoIncrement;comment:题目主键ID"`
@@ -45,12 +113,19 @@ type Challenge struct {
 	StaticFlag    *string      `json:"static_flag" gorm:"type:varchar(500);comment:静态题flag"`
 	DockerImage   *string      `json:"docker_image" gorm:"type:varchar(255);comment:动态题Docker镜像"`
 	DockerPorts   DockerPorts  `json:"docker_ports" gorm:"type:json;comment:容器端口映射"`
+	Services      ServiceSpecs `json:"services" gorm:"type:json;comment:多容器服务定义"`
+	WarmPoolSize  int          `json:"warm_pool_size" gorm:"not null;default:0;comment:预热容器数量"`
 	Difficulty    string       `json:"difficulty" gorm:"type:enum('easy','medium','hard','expert');not null;default:'medium';index:idx_difficulty;comment:题目难度"`
 	InitialScore  int          `json:"initial_score" gorm:"not null;default:100;comment:初始分值"`
 	MinScore      int          `json:"min_score" gorm:"not null;default:50;comment:最低分值"`
 	CurrentScore  int          `json:"current_score" gorm:"not null;default:100;index:idx_current_score;comment:当前分值"`
 	DecayRatio    float64      `json:"decay_ratio" gorm:"type:decimal(5,2);not null;default:0.90;comment:分数衰减比率"`
 	SolvedCount   int          `json:"solved_count" gorm:"not null;default:0;index:idx_solved_count;comment:解出次数"`
+	UnlockScore   int          `json:"unlock_score" gorm:"not null;default:0;comment:解锁所需团队分数"`
+	ReleaseAt     *time.Time   `json:"release_at" gorm:"index:idx_release_at;comment:定时放出时间"`
+	ReleaseNotice bool         `json:"release_notice" gorm:"type:tinyint(1);not null;default:0;comment:放出时是否发布公告"`
+	Tracks        ChallengeTracks `json:"tracks" gorm:"type:set('social','freshman','advanced');not null;default:'';comment:限定赛道"`
+	CreatedBy     int64        `json:"created_by" gorm:"not null;default:0;index:idx_created_by;comment:创建者用户ID"`
 	CreatedAt     time.Time    `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_created_at;comment:创建时间"`
 	UpdatedAt     time.Time    `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;comment:更新时间"`
 	DeletedAt     *time.Time   `json:"deleted_at" gorm:"index;comment:软删除时间"`
@@ -66,7 +141,22 @@ func (Challenge) TableName() string {
 
 // IsVisible 检查题目是否可见
 func (c *Challenge) IsVisible() bool {
-	return c.State == "visible" && c.DeletedAt == nil
+	return c.State == "visible" && c.DeletedAt == nil && c.IsReleased(time.Now())
+}
+
+// IsReleased 检查题目是否已到放出时间
+func (c *Challenge) IsReleased(now time.Time) bool {
+	return c.ReleaseAt == nil || !c.ReleaseAt.After(now)
+}
+
+// IsRestricted 检查题目是否限定了赛道
+func (c *Challenge) IsRestricted() bool {
+	return len(c.Tracks) > 0
+}
+
+// AllowsTrack 检查指定团队赛道能否访问该题目
+func (c *Challenge) AllowsTrack(track string) bool {
+	return !c.IsRestricted() || c.Tracks.Contains(track)
 }
 
 // IsStatic 检查是否为静态题目
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ChallengePrerequisite 题目前置依赖模型
+// 团队需解出 RequiredChallengeID 后才能看到并作答 ChallengeID
+type ChallengePrerequisite struct {
+	ID                  int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChallengeID         int64     `gorm:"not null;uniqueIndex:uk_challenge_required" json:"challenge_id"`
+	RequiredChallengeID int64     `gorm:"not null;uniqueIndex:uk_challenge_required;index:idx_required_challenge_id" json:"required_challenge_id"`
+	CreatedAt           time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (ChallengePrerequisite) TableName() string {
+	return "dalictf_challenge_prerequisite"
+}
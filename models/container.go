@@ -22,18 +22,38 @@ func (p *PortMapping) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, p)
 }
 
+// ServiceIDs 多容器题目实例的服务成员（服务名 -> Docker ID）
+type ServiceIDs map[string]string
+
+func (m ServiceIDs) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *ServiceIDs) Scan(value interface{}) error {
+	if value == nil {
+		*m = make(ServiceIDs)
+		return nil
+	}
+	bytes, _ := value.([]byte)
+	return json.Unmarshal(bytes, m)
+}
+
 // Container 容器实例模型
+// 多容器题目的一个实例对应一条记录：ContainerName 为入口服务的 Docker ID，Members 记录全部服务
 type Container struct {
 	ID            int64       `gorm:"primaryKey;autoIncrement" json:"id"`
 	ChallengeID   int64       `gorm:"not null;index" json:"challenge_id"`
 	TeamID        int64       `gorm:"not null;index" json:"team_id"`
 	UserID        int64       `gorm:"not null;index" json:"user_id"`
 	ContainerName string      `gorm:"type:varchar(255);not null" json:"container_name"` // 存储 Docker ID
+	NetworkID     string      `gorm:"type:varchar(64);not null;default:''" json:"-"`    // 多容器题目的私有网络 ID
+	Members       ServiceIDs  `gorm:"type:json" json:"-"`                               // 多容器题目的全部服务容器
 	DockerImage   string      `gorm:"type:varchar(255);not null" json:"docker_image"`
 	DockerPorts   DockerPorts `gorm:"type:json" json:"docker_ports"` // 复用 challenge.go 中的定义
 	HostMapping   PortMapping `gorm:"type:json" json:"host_mapping"`
-	ContainerFlag string      `gorm:"type:varchar(500);not null" json:"-"` // Flag 不直接返回给前端
-	State         string      `gorm:"type:enum('running','stopped','destroyed');default:'running';not null" json:"state"`
+	ContainerFlag string      `gorm:"type:varchar(500);not null" json:"-"`                 // Flag 不直接返回给前端
+	ProxyToken    string      `gorm:"type:varchar(64);not null;default:'';index" json:"-"` // 反向代理子域名令牌
+	State         string      `gorm:"type:enum('warm','running','stopped','destroyed');default:'running';not null" json:"state"`
 	StartTime     time.Time   `gorm:"autoCreateTime" json:"start_time"`
 	EndTime       time.Time   `gorm:"not null" json:"end_time"`
 	ExtendedCount int8        `gorm:"default:0;not null" json:"extended_count"`
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// 容器排队状态
+const (
+	QueueWaiting   = "waiting"   // 排队中
+	QueueStarting  = "starting"  // 已出队，容器启动中
+	QueueStarted   = "started"   // 容器已启动
+	QueueFailed    = "failed"    // 启动失败
+	QueueCancelled = "cancelled" // 用户取消或长时间未轮询
+)
+
+// ContainerQueue 容器启动排队记录，全局容量已满时按先进先出顺序启动
+type ContainerQueue struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChallengeID int64     `gorm:"not null;index" json:"challenge_id"`
+	TeamID      int64     `gorm:"not null;index" json:"team_id"`
+	UserID      int64     `gorm:"not null" json:"user_id"`
+	State       string    `gorm:"type:enum('waiting','starting','started','failed','cancelled');not null;default:'waiting';index" json:"state"`
+	ContainerID int64     `gorm:"not null;default:0" json:"container_id"` // 启动成功后的容器记录ID
+	Error       string    `gorm:"type:varchar(255);not null;default:''" json:"error"`
+	PolledAt    time.Time `gorm:"not null" json:"polled_at"` // 最后一次轮询时间
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ContainerQueue) TableName() string {
+	return "dalictf_container_queue"
+}
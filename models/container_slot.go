@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ContainerSlot 全局容器容量名额，启动中、运行中及预热中的容器各占用一个，容器销毁时释放
+// 名额编号受唯一索引约束，多实例部署时同样不会超出容量
+type ContainerSlot struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Slot        int       `gorm:"not null;uniqueIndex:uk_slot" json:"slot"`
+	ContainerID int64     `gorm:"not null;default:0;index" json:"container_id"` // 0 表示启动中、容器尚未创建
+	TeamID      int64     `gorm:"not null;default:0;index" json:"team_id"`      // 预热容器为 0，被领取后更新为团队
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (ContainerSlot) TableName() string {
+	return "dalictf_container_slot"
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// ChallengeHint 题目提示模型（支持多条提示、扣分解锁、定时放出）
+type ChallengeHint struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChallengeID int64      `gorm:"not null;index:idx_challenge_id" json:"challenge_id"`
+	Content     string     `gorm:"type:text;not null" json:"content"`
+	Cost        int        `gorm:"default:0;not null" json:"cost"` // 解锁扣除的团队分数，0 表示免费
+	ReleaseAt   *time.Time `gorm:"default:null" json:"release_at"` // 放出时间，为空表示立即可见
+	SortOrder   int        `gorm:"default:0;not null" json:"sort_order"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TableName 指定表名
+func (ChallengeHint) TableName() string {
+	return "dalictf_challenge_hint"
+}
+
+// IsReleased 检查提示是否已放出
+func (h *ChallengeHint) IsReleased(now time.Time) bool {
+	return h.ReleaseAt == nil || !h.ReleaseAt.After(now)
+}
+
+// IsFree 检查提示是否免费
+func (h *ChallengeHint) IsFree() bool {
+	return h.Cost <= 0
+}
+
+// HintUnlock 提示解锁记录模型
+type HintUnlock struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	HintID      int64     `gorm:"not null;uniqueIndex:uk_team_hint" json:"hint_id"`
+	ChallengeID int64     `gorm:"not null;index" json:"challenge_id"`
+	TeamID      int64     `gorm:"not null;uniqueIndex:uk_team_hint" json:"team_id"`
+	UserID      int64     `gorm:"not null" json:"user_id"`
+	Cost        int       `gorm:"not null" json:"cost"` // 解锁时实际扣除的分数
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (HintUnlock) TableName() string {
+	return "dalictf_hint_unlock"
+}
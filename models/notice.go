@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Notice 系统公告模型
+type Notice struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title       string     `gorm:"type:varchar(255);not null" json:"title"`
+	Content     string     `gorm:"type:text;not null" json:"content"`
+	IsTop       bool       `gorm:"type:tinyint(1);default:0;not null;index:idx_is_top" json:"is_top"`
+	Status      string     `gorm:"type:enum('published','draft','archived');default:'published';not null;index:idx_status" json:"status"`
+	ChallengeID *int64     `gorm:"default:null;index:idx_challenge_id" json:"challenge_id"` // 关联题目（提示/修复公告），为空表示全局公告
+	CreatedBy   int64      `gorm:"not null" json:"created_by"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index:idx_created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TableName 指定表名
+func (Notice) TableName() string {
+	return "dalictf_notice"
+}
+
+// IsPublished 检查公告是否已发布
+func (n *Notice) IsPublished() bool {
+	return n.Status == "published" && n.DeletedAt == nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordToken 一次性密码令牌模型（设置密码/重置密码链接）
+// 仅保存令牌的 SHA-256 摘要，明文只出现在发送给用户的链接中
+type PasswordToken struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"not null;index:idx_user_id" json:"user_id"`
+	TokenHash string     `gorm:"type:char(64);not null;uniqueIndex:uk_token_hash" json:"-"`
+	Purpose   string     `gorm:"type:enum('set_password','reset_password');not null" json:"purpose"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"default:null" json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (PasswordToken) TableName() string {
+	return "dalictf_password_token"
+}
+
+// IsUsable 检查令牌是否未使用且未过期
+func (t *PasswordToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
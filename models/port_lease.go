@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PortLease 宿主机端口租约，容器销毁时释放
+type PortLease struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Port        int       `gorm:"not null;uniqueIndex:uk_port" json:"port"`
+	ContainerID int64     `gorm:"not null;default:0;index" json:"container_id"` // 0 表示已分配、容器尚未创建
+	TeamID      int64     `gorm:"not null" json:"team_id"`
+	ChallengeID int64     `gorm:"not null" json:"challenge_id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (PortLease) TableName() string {
+	return "dalictf_port_lease"
+}
@@ -0,0 +1,29 @@
+package models
+
+// 权限标识
+const (
+	PermChallengeWrite    = "challenge.write"     // 管理全部题目（含附件、提示、依赖、定时放出）
+	PermChallengeWriteOwn = "challenge.write.own" // 仅管理自己创建的题目
+	PermCategoryWrite     = "category.write"      // 管理题目分类
+	PermNoticeWrite       = "notice.write"        // 管理公告
+	PermTeamBan           = "team.ban"            // 封禁/解封团队
+	PermUserManage        = "user.manage"         // 查看用户、封禁/解封用户
+	PermUserRoleAssign    = "user.role.assign"    // 修改用户角色（授予管理员仍需超级管理员）
+	PermSchoolManage      = "school.manage"       // 管理学校及学校名单
+	PermStudentReview     = "student.review"      // 审核、导入学生（院校负责人仅限本校）
+	PermContainerManage   = "container.manage"    // 管理全部题目容器
+	PermAuditView         = "audit.view"          // 查看管理操作审计日志
+	PermPermissionManage  = "permission.manage"   // 管理角色权限（仅超级管理员，不可分配）
+)
+
+// RolePermission 角色权限映射模型
+type RolePermission struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Role       string `gorm:"type:varchar(50);not null;uniqueIndex:uk_role_permission" json:"role"`
+	Permission string `gorm:"type:varchar(50);not null;uniqueIndex:uk_role_permission" json:"permission"`
+}
+
+// TableName 指定表名
+func (RolePermission) TableName() string {
+	return "dalictf_role_permission"
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// SchoolRoster 学校学生名单模型（用于院校赛道注册自动核验）
+type SchoolRoster struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	SchoolID      int64      `gorm:"not null;uniqueIndex:uk_school_student_number" json:"school_id"`
+	StudentNumber string     `gorm:"type:varchar(50);not null;uniqueIndex:uk_school_student_number" json:"student_number"`
+	UserName      string     `gorm:"type:varchar(50);not null" json:"user_name"`
+	MatchedUserID *int64     `gorm:"default:null" json:"matched_user_id"` // 已匹配注册的用户ID，每条名单仅可匹配一次
+	MatchedAt     *time.Time `gorm:"default:null" json:"matched_at"`
+	CreatedBy     int64      `gorm:"not null" json:"created_by"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (SchoolRoster) TableName() string {
+	return "dalictf_school_roster"
+}
+
+// IsMatched 检查名单条目是否已被注册用户匹配
+func (r *SchoolRoster) IsMatched() bool {
+	return r.MatchedUserID != nil
+}
@@ -12,7 +12,7 @@ type User struct {
 	Username            string     `gorm:"type:varchar(50);not null;uniqueIndex:uk_username" json:"username"`
 	Password            string     `gorm:"type:varchar(255);not null" json:"-"`
 	Email               string     `gorm:"type:varchar(100);not null;uniqueIndex:uk_email" json:"email"`
-	Role                string     `gorm:"type:enum('user','school_admin','admin','super_admin');default:'user';not null" json:"role"`
+	Role                string     `gorm:"type:enum('user','challenge_author','school_admin','admin','super_admin');default:'user';not null" json:"role"`
 	Track               string     `gorm:"type:enum('social','school');default:'social';not null" json:"track"`
 	SchoolID            *int64     `gorm:"default:null" json:"school_id"`
 	SchoolName          *string    `gorm:"type:varchar(255);default:null" json:"school_name"`
@@ -31,6 +31,13 @@ type User struct {
 	Status              string     `gorm:"type:enum('active','suspended');default:'active';not null" json:"status"`
 	LastLoginTime       *time.Time `gorm:"default:null" json:"last_login_time"`
 	LastLoginIP         *string    `gorm:"type:varchar(50);default:null" json:"last_login_ip"`
+	TokensValidAfter    *time.Time `gorm:"default:null" json:"-"` // 早于该时间签发的 Token 全部失效（重置密码等场景）
+	TOTPSecret          *string    `gorm:"column:totp_secret;type:varchar(64);default:null" json:"-"`
+	TOTPEnabled         bool       `gorm:"column:totp_enabled;default:0;not null" json:"totp_enabled"`
+	TOTPRecoveryCodes   *string    `gorm:"column:totp_recovery_codes;type:text;default:null" json:"-"` // 恢复码 SHA-256 摘要，逗号分隔
+	TOTPLastStep        int64      `gorm:"column:totp_last_step;default:0;not null" json:"-"`          // 最近一次通过验证的时间步，防止验证码重放
+	TOTPFailCount       int        `gorm:"column:totp_fail_count;default:0;not null" json:"-"`
+	TOTPFailedAt        *time.Time `gorm:"column:totp_failed_at;default:null" json:"-"`
 	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt           *time.Time `gorm:"index" json:"deleted_at,omitempty"`
@@ -57,6 +64,16 @@ func (u *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// IsTokenRevoked 检查指定时间签发的 Token 是否已被吊销
+func (u *User) IsTokenRevoked(issuedAt time.Time) bool {
+	return u.TokensValidAfter != nil && issuedAt.Before(*u.TokensValidAfter)
+}
+
+// HasTwoFactor 检查是否已启用两步验证
+func (u *User) HasTwoFactor() bool {
+	return u.TOTPEnabled && u.TOTPSecret != nil
+}
+
 // IsActive 检查用户是否处于正常状态
 func (u *User) IsActive() bool {
 	return u.Status == "active"
@@ -72,6 +89,11 @@ func (u *User) IsAdmin() bool {
 	return u.Role == "admin" || u.Role == "super_admin"
 }
 
+// IsSuperAdmin 检查是否是超级管理员
+func (u *User) IsSuperAdmin() bool {
+	return u.Role == "super_admin"
+}
+
 // IsSchoolAdmin 检查是否是学校管理员
 func (u *User) IsSchoolAdmin() bool {
 	return u.Role == "school_admin"
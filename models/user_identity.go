@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity 外部身份绑定模型（OIDC 提供方的 subject 与平台用户的对应关系）
+type UserIdentity struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      int64      `gorm:"not null;index:idx_user_id" json:"user_id"`
+	Provider    string     `gorm:"type:varchar(50);not null;uniqueIndex:uk_provider_subject" json:"provider"`
+	Subject     string     `gorm:"type:varchar(255);not null;uniqueIndex:uk_provider_subject" json:"subject"`
+	Email       *string    `gorm:"type:varchar(100);default:null" json:"email"`
+	LastLoginAt *time.Time `gorm:"default:null" json:"last_login_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserIdentity) TableName() string {
+	return "dalictf_user_identity"
+}
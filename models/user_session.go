@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// UserSession 用户登录会话模型（保存 Refresh Token 摘要及设备信息）
+type UserSession struct {
+	ID                int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID            int64      `gorm:"not null;index:idx_user_id" json:"user_id"`
+	RefreshTokenHash  string     `gorm:"type:char(64);not null;uniqueIndex:uk_refresh_token_hash" json:"-"`
+	PreviousTokenHash *string    `gorm:"type:char(64);default:null;index:idx_previous_token_hash" json:"-"` // 上一次轮换前的摘要，用于发现 Refresh Token 重放
+	UserAgent         string     `gorm:"type:varchar(255);not null;default:''" json:"user_agent"`
+	IP                string     `gorm:"type:varchar(50);not null;default:''" json:"ip"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt        time.Time  `gorm:"not null" json:"last_used_at"`
+	TwoFactor         bool       `gorm:"default:0;not null" json:"two_factor"` // 登录时是否通过两步验证
+	RevokedAt         *time.Time `gorm:"default:null" json:"revoked_at"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "dalictf_user_session"
+}
+
+// IsActive 检查会话是否有效（未吊销且未过期）
+func (s *UserSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
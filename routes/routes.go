@@ -3,6 +3,7 @@ package routes
 import (
 	"isctf/controllers"
 	"isctf/middleware"
+	"isctf/models"
 	"isctf/utils"
 
 	"github.com/gin-gonic/gin"
@@ -13,9 +14,10 @@ func SetupRouter() *gin.Engine {
 	r := gin.New()
 
 	// 全局中间件
-	r.Use(gin.Recovery())                // 异常恢复中间件
-	r.Use(middleware.LoggerMiddleware()) // 自定义日志中间件
-	r.Use(middleware.CORSMiddleware())   // 跨域中间件
+	r.Use(gin.Recovery())                        // 异常恢复中间件
+	r.Use(middleware.LoggerMiddleware())         // 自定义日志中间件
+	r.Use(middleware.ContainerProxyMiddleware()) // 容器子域名反向代理（需在跨域中间件之前）
+	r.Use(middleware.CORSMiddleware())           // 跨域中间件
 
 	// 实例化控制器
 	userController := controllers.NewUserController()
@@ -23,6 +25,18 @@ func SetupRouter() *gin.Engine {
 	teamController := controllers.NewTeamController()
 	categoryController := controllers.NewCategoryController()
 	challengeController := controllers.NewChallengeController()
+	noticeController := controllers.NewNoticeController()
+	hintController := controllers.NewHintController()
+	prerequisiteController := controllers.NewPrerequisiteController()
+	studentImportController := controllers.NewStudentImportController()
+	schoolRosterController := controllers.NewSchoolRosterController()
+	sessionController := controllers.NewSessionController()
+	twoFactorController := controllers.NewTwoFactorController()
+	oidcController := controllers.NewOIDCController()
+	permissionController := controllers.NewPermissionController()
+	auditController := controllers.NewAuditController()
+	dashboardController := controllers.NewDashboardController()
+	containerGatewayController := controllers.NewContainerGatewayController()
 
 	// 健康检查接口（不需要认证）
 	r.GET("/ping", func(c *gin.Context) {
@@ -32,6 +46,9 @@ func SetupRouter() *gin.Engine {
 		})
 	})
 
+	// JWT 公钥集合（EdDSA/RS256 模式下供其他服务验签）
+	r.GET("/.well-known/jwks.json", sessionController.GetJWKS)
+
 	// API v1 路由组
 	v1 := r.Group("/api/v1")
 	{
@@ -42,13 +59,25 @@ func SetupRouter() *gin.Engine {
 		{
 			// 用户认证
 			public.POST("/login", userController.Login)
+			public.POST("/login/2fa", userController.LoginTwoFactor)       // 登录第二步：两步验证
+			public.POST("/token/refresh", sessionController.Refresh)       // 使用 Refresh Token 换取新 Token
 			public.POST("/register/social", userController.RegisterSocial) // 社会赛道注册
 			public.POST("/register/school", userController.RegisterSchool) // 院校赛道注册
 
+			// 统一身份认证（OIDC）登录
+			public.GET("/oidc/providers", oidcController.GetProviders)
+			public.POST("/oidc/:provider/authorize", oidcController.Authorize) // 获取认证跳转地址
+			public.POST("/oidc/:provider/callback", oidcController.Callback)   // 提交回调参数完成登录（未绑定时自动注册）
+
 			// 验证码
 			public.POST("/verify/email/send", userController.SendVerifyCode)
 			public.POST("/verify/email/check", userController.VerifyEmail)
 
+			// 通过邮件一次性链接设置/重置密码
+			public.POST("/password/set", userController.SetPassword)
+			public.POST("/password/reset/request", userController.RequestPasswordReset)
+			public.POST("/password/reset/confirm", userController.ConfirmPasswordReset)
+
 			// 公开查询 - 学校
 			public.GET("/schools", schoolController.GetSchools)                        // 获取学校列表
 			public.GET("/schools/:id", schoolController.GetSchoolByID)                 // 获取学校详情
@@ -60,15 +89,24 @@ func SetupRouter() *gin.Engine {
 			public.GET("/teams/rank", teamController.GetTeamRank) // 获取团队排名
 
 			// 公开查询 - 题目与分类
-			public.GET("/categories", categoryController.GetList)        // 获取题目分类列表
-			public.GET("/categories/:id", categoryController.GetDetail)  // 获取分类详情
-			public.GET("/challenges", challengeController.GetList)       // 获取题目列表
-			public.GET("/challenges/:id", challengeController.GetDetail) // 获取题目详情
+			public.GET("/categories", categoryController.GetList)                                             // 获取题目分类列表
+			public.GET("/categories/:id", categoryController.GetDetail)                                       // 获取分类详情
+			public.GET("/challenges", middleware.OptionalAuthMiddleware(), challengeController.GetList)       // 获取题目列表（登录后按团队解锁进度过滤）
+			public.GET("/challenges/:id", middleware.OptionalAuthMiddleware(), challengeController.GetDetail) // 获取题目详情
 
 			// 公开查询 - 解题动态
 			public.GET("/logs/solves", challengeController.GetRecentSolves)    // 获取最新解题动态
 			public.GET("/teams/:id/solves", challengeController.GetTeamSolves) // 获取特定团队解题记录
 			public.GET("/users/:id/solves", challengeController.GetUserSolves) // 获取特定用户解题记录
+
+			// 公开查询 - 公告
+			public.GET("/notices", middleware.OptionalAuthMiddleware(), noticeController.GetNotices)        // 获取公告列表（支持 ETag 轮询，登录后按团队可见题目过滤）
+			public.GET("/notices/:id", middleware.OptionalAuthMiddleware(), noticeController.GetNoticeByID) // 获取公告详情
+
+			// 公开查询 - 比赛大屏
+			public.GET("/dashboard/overview", dashboardController.GetOverview)  // 获取大屏聚合数据（缓存 5 秒）
+			public.GET("/dashboard/trend", dashboardController.GetTrend)        // 获取得分趋势（缓存 30 秒）
+			public.GET("/dashboard/matrix", dashboardController.GetSolveMatrix) // 获取解题矩阵（支持按赛道筛选）
 		}
 
 		// ---------------------------
@@ -77,12 +115,29 @@ func SetupRouter() *gin.Engine {
 		auth := v1.Group("")
 		auth.Use(middleware.AuthMiddleware())
 		{
+			auth.POST("/logout", sessionController.Logout) // 退出登录（吊销当前会话）
+
 			// 用户个人中心
 			users := auth.Group("/users")
 			{
-				users.GET("/profile", userController.GetProfile)      // 获取个人信息
-				users.PUT("/profile", userController.UpdateProfile)   // 更新个人信息
-				users.PUT("/password", userController.ChangePassword) // 修改密码
+				users.GET("/profile", userController.GetProfile)                 // 获取个人信息
+				users.PUT("/profile", userController.UpdateProfile)              // 更新个人信息
+				users.PUT("/password", userController.ChangePassword)            // 修改密码
+				users.GET("/sessions", sessionController.GetMySessions)          // 我的登录会话
+				users.DELETE("/sessions/:id", sessionController.RevokeMySession) // 远程下线会话
+
+				// 两步验证（TOTP）
+				users.GET("/2fa", twoFactorController.GetStatus)
+				users.POST("/2fa/setup", twoFactorController.Setup)                            // 获取绑定密钥与二维码链接
+				users.POST("/2fa/enable", twoFactorController.Enable)                          // 校验验证码并启用
+				users.POST("/2fa/disable", twoFactorController.Disable)                        // 关闭（需密码与验证码）
+				users.POST("/2fa/recovery-codes", twoFactorController.RegenerateRecoveryCodes) // 重新生成恢复码
+
+				// 统一身份认证绑定
+				users.GET("/identities", oidcController.GetMyIdentities)
+				users.POST("/identities/:provider/authorize", oidcController.AuthorizeLink)
+				users.POST("/identities/:provider/callback", oidcController.LinkCallback)
+				users.DELETE("/identities/:id", oidcController.Unlink)
 			}
 
 			// 团队管理（学生端）
@@ -102,69 +157,142 @@ func SetupRouter() *gin.Engine {
 			challenges := auth.Group("/challenges")
 			{
 				challenges.POST("/:id/submit", challengeController.SubmitFlag)                                     // 提交 Flag
-				challenges.POST("/:id/container/start", challengeController.StartContainer)                        // 启动容器
+				challenges.POST("/:id/container/start", challengeController.StartContainer)                        // 启动容器（?queue=true 容量已满时排队）
+				challenges.GET("/:id/container/queue", challengeController.GetContainerQueue)                      // 轮询排队状态
+				challenges.GET("/:id/container/url", challengeController.GetContainerURL)                          // 重新获取反向代理访问地址
+				challenges.DELETE("/:id/container/queue", challengeController.CancelContainerQueue)                // 取消排队
 				challenges.POST("/:id/container/stop", challengeController.StopContainer)                          // 停止容器
 				challenges.POST("/:id/container/renew", challengeController.RenewContainer)                        // 续期容器
 				challenges.GET("/:id/container/status", challengeController.GetContainerStatus)                    // 获取容器状态
 				challenges.GET("/:id/attachments/:attachment_id/download", challengeController.DownloadAttachment) // 下载附件
+				challenges.GET("/:id/hints", hintController.GetHints)                                              // 获取题目提示
+				challenges.POST("/:id/hints/:hint_id/unlock", hintController.UnlockHint)                           // 解锁提示（扣分）
 			}
 
 			// 容器管理（用户侧）
 			containers := auth.Group("/containers")
 			{
 				containers.POST("/destroy", challengeController.DestroyContainer) // 销毁容器
+				containers.GET("/:id/tcp", containerGatewayController.Connect)    // TCP-over-WebSocket 中转（pwn 题）
 			}
 
 			// ---------------------------
-			// 管理员/负责人接口
+			// 管理接口（按角色权限控制，权限配置见 dalictf_role_permission）
 			// ---------------------------
 
-			// 学校管理员/管理员接口
-			schoolAdmin := auth.Group("")
-			schoolAdmin.Use(middleware.SchoolAdminMiddleware())
+			// 学生审核（院校负责人仅限本校）
+			review := auth.Group("")
+			review.Use(middleware.RequirePermission(models.PermStudentReview))
 			{
-				// 审核学生
-				schoolAdmin.POST("/users/:id/verify", userController.VerifyStudent)
+				review.POST("/users/:id/verify", userController.VerifyStudent)
+				review.POST("/schools/users/:id/review", userController.VerifyStudent)                  // 审核学生信息
+				review.POST("/schools/users/review/batch", userController.BatchVerifyStudents)          // 批量审核学生
+				review.GET("/schools/review/reason-templates", userController.GetVerifyReasonTemplates) // 驳回理由模板
+				review.GET("/schools/:id/pending-users", userController.GetPendingStudents)             // 查看待审核学生列表
+				review.GET("/schools/:id/users", userController.GetSchoolStudents)                      // 查看本校所有学生
+				review.POST("/schools/:id/users/import", studentImportController.ImportStudents)        // 批量导入学生（CSV/XLSX）
 			}
 
-			// 系统管理员接口
-			admin := auth.Group("")
-			admin.Use(middleware.AdminMiddleware())
+			// 学校管理
+			schools := auth.Group("")
+			schools.Use(middleware.RequirePermission(models.PermSchoolManage))
 			{
-				// 学校管理
-				admin.POST("/schools", schoolController.CreateSchool)
-				admin.PUT("/schools/:id", schoolController.UpdateSchool)
-				admin.DELETE("/schools/:id", schoolController.DeleteSchool)
-				admin.PATCH("/schools/:id/status", schoolController.UpdateSchoolStatus)
-
-				// 用户管理
-				admin.GET("/users", userController.GetUserList)                   // 获取用户列表
-				admin.GET("/users/:id", userController.GetUserByID)               // 获取用户详情
-				admin.PATCH("/users/:id/role", userController.UpdateUserRole)     // 修改用户角色
-				admin.PATCH("/users/:id/status", userController.UpdateUserStatus) // 修改用户状态
-
-				// 团队管理
-				admin.PATCH("/teams/:id/status", teamController.UpdateTeamStatus) // 管理团队状态
-
-				// 分类管理
-				admin.POST("/categories", categoryController.Create)
-				admin.PUT("/categories/:id", categoryController.Update)
-				admin.DELETE("/categories/:id", categoryController.Delete)
-				admin.PATCH("/categories/:id/status", categoryController.UpdateStatus)
-
-				// 题目管理
-				admin.POST("/challenges", challengeController.Create)
-				admin.PUT("/challenges/:id", challengeController.Update)
-				admin.DELETE("/challenges/:id", challengeController.Delete)
-				admin.PATCH("/challenges/:id/state", challengeController.UpdateState)
+				schools.POST("/schools", schoolController.CreateSchool)
+				schools.PUT("/schools/:id", schoolController.UpdateSchool)
+				schools.DELETE("/schools/:id", schoolController.DeleteSchool)
+				schools.PATCH("/schools/:id/status", schoolController.UpdateSchoolStatus)
+
+				// 学校名单（注册自动核验）
+				schools.GET("/admin/schools/:id/roster", schoolRosterController.GetRoster)
+				schools.POST("/admin/schools/:id/roster", schoolRosterController.AddEntries)
+				schools.DELETE("/admin/schools/:id/roster/:entry_id", schoolRosterController.DeleteEntry)
+			}
+
+			// 用户管理
+			userAdmin := auth.Group("")
+			userAdmin.Use(middleware.RequirePermission(models.PermUserManage))
+			{
+				userAdmin.GET("/users", userController.GetUserList)                   // 获取用户列表
+				userAdmin.GET("/users/:id", userController.GetUserByID)               // 获取用户详情
+				userAdmin.PATCH("/users/:id/status", userController.UpdateUserStatus) // 修改用户状态
+			}
+			auth.PATCH("/users/:id/role", middleware.RequirePermission(models.PermUserRoleAssign), userController.UpdateUserRole) // 修改用户角色（授予管理员需超级管理员）
+
+			// 团队管理
+			auth.PATCH("/teams/:id/status", middleware.RequirePermission(models.PermTeamBan), teamController.UpdateTeamStatus) // 封禁/解封团队
+
+			// 分类管理
+			categories := auth.Group("")
+			categories.Use(middleware.RequirePermission(models.PermCategoryWrite))
+			{
+				categories.POST("/categories", categoryController.Create)
+				categories.PUT("/categories/:id", categoryController.Update)
+				categories.DELETE("/categories/:id", categoryController.Delete)
+				categories.PATCH("/categories/:id/status", categoryController.UpdateStatus)
+			}
+
+			// 题目管理（出题人仅能管理自己创建的题目）
+			chalAdmin := auth.Group("")
+			chalAdmin.Use(middleware.RequirePermission(models.PermChallengeWrite, models.PermChallengeWriteOwn), middleware.RequireChallengeOwnership())
+			{
+				chalAdmin.GET("/admin/challenges", challengeController.GetAdminList)       // 题目列表（含 Flag、镜像及隐藏题目，出题人仅返回自己创建的）
+				chalAdmin.GET("/admin/challenges/:id", challengeController.GetAdminDetail) // 题目详情
+				chalAdmin.POST("/challenges", challengeController.Create)
+				chalAdmin.PUT("/challenges/:id", challengeController.Update)
+				chalAdmin.DELETE("/challenges/:id", challengeController.Delete)
+				chalAdmin.PATCH("/challenges/:id/state", challengeController.UpdateState)
+				chalAdmin.PATCH("/challenges/:id/release", challengeController.ScheduleRelease) // 设置定时放出
 
 				// 附件管理
-				admin.POST("/challenges/:id/attachments", challengeController.UploadAttachment)
-				admin.DELETE("/challenges/:id/attachments/:attachment_id", challengeController.DeleteAttachment)
+				chalAdmin.POST("/challenges/:id/attachments", challengeController.UploadAttachment)
+				chalAdmin.DELETE("/challenges/:id/attachments/:attachment_id", challengeController.DeleteAttachment)
+
+				// 题目依赖
+				chalAdmin.PUT("/challenges/:id/prerequisites", prerequisiteController.SetPrerequisites)
+
+				// 提示管理
+				chalAdmin.GET("/admin/challenges/:id/hints", hintController.GetAdminHints) // 提示列表及解锁记录
+				chalAdmin.POST("/challenges/:id/hints", hintController.CreateHint)
+				chalAdmin.PUT("/challenges/:id/hints/:hint_id", hintController.UpdateHint)
+				chalAdmin.DELETE("/challenges/:id/hints/:hint_id", hintController.DeleteHint)
+			}
+
+			// 全局题目视图（涉及全部题目，需管理全部题目权限）
+			chalAll := auth.Group("")
+			chalAll.Use(middleware.RequirePermission(models.PermChallengeWrite))
+			{
+				chalAll.GET("/admin/challenges/releases", challengeController.GetUpcomingReleases) // 待放出题目列表
+				chalAll.GET("/admin/challenges/prerequisites", prerequisiteController.GetGraph)    // 题目依赖图
+			}
 
-				// 容器管理
-				admin.GET("/admin/containers", challengeController.GetAdminContainers)
-				admin.POST("/admin/containers/:id/stop", challengeController.AdminStopContainer)
+			// 容器管理
+			containerAdmin := auth.Group("")
+			containerAdmin.Use(middleware.RequirePermission(models.PermContainerManage))
+			{
+				containerAdmin.GET("/admin/containers", challengeController.GetAdminContainers)
+				containerAdmin.POST("/admin/containers/:id/stop", challengeController.AdminStopContainer)
+			}
+
+			// 公告管理
+			notices := auth.Group("")
+			notices.Use(middleware.RequirePermission(models.PermNoticeWrite))
+			{
+				notices.GET("/admin/notices", noticeController.GetAdminNotices)
+				notices.POST("/admin/notices", noticeController.CreateNotice)
+				notices.PUT("/admin/notices/:id", noticeController.UpdateNotice)
+				notices.DELETE("/admin/notices/:id", noticeController.DeleteNotice)
+				notices.PATCH("/admin/notices/:id/top", noticeController.UpdateNoticeTop)
+			}
+
+			// 审计日志
+			auth.GET("/admin/audit-logs", middleware.RequirePermission(models.PermAuditView), auditController.GetAuditLogs)
+
+			// 角色权限管理（仅超级管理员）
+			permissions := auth.Group("/admin")
+			permissions.Use(middleware.RequirePermission(models.PermPermissionManage))
+			{
+				permissions.GET("/roles/permissions", permissionController.GetRolePermissions)
+				permissions.PUT("/roles/:role/permissions", permissionController.SetRolePermissions)
 			}
 		}
 	}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"encoding/json"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"reflect"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// auditMask 敏感字段在审计日志中的占位值
+const auditMask = "******"
+
+// auditSensitiveFields 只记录是否变更、不记录明文的字段
+var auditSensitiveFields = map[string]bool{
+	"static_flag":         true,
+	"password":            true,
+	"totp_secret":         true,
+	"totp_recovery_codes": true,
+}
+
+// auditIgnoredFields 不参与比较的字段
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// Operator 管理操作的执行者，由控制器根据登录信息构造
+type Operator struct {
+	UserID   int64
+	Username string
+	Role     string
+	IP       string
+}
+
+// AuditService 审计日志服务
+type AuditService struct{}
+
+// NewAuditService 创建审计日志服务实例
+func NewAuditService() *AuditService {
+	return &AuditService{}
+}
+
+// Record 写入审计日志，应与被审计的操作在同一事务中调用
+// before/after 为变更前后的对象（模型或 map），创建时 before 为 nil，删除时 after 为 nil
+func (s *AuditService) Record(tx *gorm.DB, op *Operator, action, targetType string, targetID int64, before, after interface{}) error {
+	return s.RecordKey(tx, op, action, targetType, strconv.FormatInt(targetID, 10), before, after)
+}
+
+// RecordKey 写入审计日志，目标以字符串标识（如角色名）
+func (s *AuditService) RecordKey(tx *gorm.DB, op *Operator, action, targetType, targetKey string, before, after interface{}) error {
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+
+	entry := &models.AuditLog{
+		ActorName:  "system",
+		ActorRole:  "system",
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetKey,
+		Changes:    changes,
+	}
+	if op != nil {
+		entry.ActorID = op.UserID
+		entry.ActorName = op.Username
+		entry.ActorRole = op.Role
+		entry.IP = op.IP
+	}
+	return tx.Create(entry).Error
+}
+
+// GetAuditLogs 查询审计日志，按时间倒序
+func (s *AuditService) GetAuditLogs(req *dto.AuditLogListRequest) (*dto.AuditLogListResponse, error) {
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	query := config.DB.Model(&models.AuditLog{})
+	if req.ActorID != 0 {
+		query = query.Where("actor_id = ?", req.ActorID)
+	}
+	if req.Action != "" {
+		query = query.Where("action LIKE ?", req.Action+"%")
+	}
+	if req.TargetType != "" {
+		query = query.Where("target_type = ?", req.TargetType)
+	}
+	if req.TargetID != "" {
+		query = query.Where("target_id = ?", req.TargetID)
+	}
+	if req.IP != "" {
+		query = query.Where("ip = ?", req.IP)
+	}
+	if req.StartTime != nil {
+		query = query.Where("created_at >= ?", *req.StartTime)
+	}
+	if req.EndTime != nil {
+		query = query.Where("created_at < ?", *req.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	var logs []models.AuditLog
+	if err := query.Order("id DESC").Offset(offset).Limit(req.Limit).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.AuditLogResponse, 0, len(logs))
+	for _, l := range logs {
+		changes := make(map[string]dto.AuditChangeResponse, len(l.Changes))
+		for field, c := range l.Changes {
+			changes[field] = dto.AuditChangeResponse{Before: c.Before, After: c.After}
+		}
+		list = append(list, dto.AuditLogResponse{
+			ID:         l.ID,
+			ActorID:    l.ActorID,
+			ActorName:  l.ActorName,
+			ActorRole:  l.ActorRole,
+			Action:     l.Action,
+			TargetType: l.TargetType,
+			TargetID:   l.TargetID,
+			Changes:    changes,
+			IP:         l.IP,
+			CreatedAt:  l.CreatedAt,
+		})
+	}
+
+	return &dto.AuditLogListResponse{
+		Total: int(total),
+		Page:  req.Page,
+		Limit: req.Limit,
+		List:  list,
+	}, nil
+}
+
+// auditDiff 按 JSON 字段比较变更前后的对象，仅保留发生变化的字段，敏感字段以占位值记录
+func auditDiff(before, after interface{}) (models.AuditChanges, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(models.AuditChanges)
+	for _, fields := range []map[string]interface{}{beforeFields, afterFields} {
+		for field := range fields {
+			if auditIgnoredFields[field] {
+				continue
+			}
+			if _, done := changes[field]; done {
+				continue
+			}
+			b, a := beforeFields[field], afterFields[field]
+			if reflect.DeepEqual(b, a) {
+				continue
+			}
+			if auditSensitiveFields[field] {
+				b, a = auditMaskValue(b), auditMaskValue(a)
+			}
+			changes[field] = models.AuditChange{Before: b, After: a}
+		}
+	}
+	return changes, nil
+}
+
+// auditFields 将对象转换为 JSON 字段集合，json:"-" 的字段（如密码哈希）不会出现
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// auditMaskValue 隐藏敏感字段的值，空值保持为空以体现设置/清除
+func auditMaskValue(v interface{}) interface{} {
+	if v == nil || v == "" {
+		return v
+	}
+	return auditMask
+}
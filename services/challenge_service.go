@@ -7,10 +7,13 @@ import (
 	"isctf/dto"
 	"isctf/models"
 	"isctf/utils"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChallengeService struct{}
@@ -19,8 +22,12 @@ func NewChallengeService() *ChallengeService {
 	return &ChallengeService{}
 }
 
-// CreateChallenge 创建题目
-func (s *ChallengeService) CreateChallenge(req *dto.ChallengeRequest) (*models.Challenge, error) {
+// CreateChallenge 创建题目，操作者记为创建者（出题人仅能管理自己创建的题目）
+func (s *ChallengeService) CreateChallenge(op *Operator, req *dto.ChallengeRequest) (*dto.AdminChallengeResponse, error) {
+	if err := validateChallengeRequest(req); err != nil {
+		return nil, err
+	}
+
 	chal := &models.Challenge{
 		ChallengeName: req.ChallengeName,
 		Direction:     req.Direction,
@@ -31,34 +38,287 @@ func (s *ChallengeService) CreateChallenge(req *dto.ChallengeRequest) (*models.C
 		Mode:          req.Mode,
 		StaticFlag:    req.StaticFlag,
 		DockerImage:   req.DockerImage,
+		Services:      newServiceSpecs(req.Services),
+		WarmPoolSize:  req.WarmPoolSize,
 		Difficulty:    req.Difficulty,
 		InitialScore:  req.InitialScore,
 		MinScore:      req.MinScore,
 		CurrentScore:  req.InitialScore,
 		DecayRatio:    req.DecayRatio,
 		SolvedCount:   0,
+		ReleaseAt:     req.ReleaseAt,
+		ReleaseNotice: req.ReleaseNotice,
+		Tracks:        models.ChallengeTracks(req.Tracks),
+		CreatedBy:     op.UserID,
 	}
 	if req.DockerPorts != nil {
 		chal.DockerPorts = models.DockerPorts(req.DockerPorts)
 	}
 
-	if err := config.DB.Create(chal).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(chal).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditChallengeCreate, "challenge", chal.ID, nil, chal)
+	})
+	if err != nil {
+		return nil, err
+	}
+	pullChallengeImage(chal)
+	resp := newAdminChallengeResponse(chal)
+	return &resp, nil
+}
+
+// UpdateChallenge 更新题目（管理员），分值参数变化时按已解出次数重新计算当前分值
+// 修改已被解出题目的 Flag 不影响已有解题记录，返回提示供管理员确认
+func (s *ChallengeService) UpdateChallenge(op *Operator, id int64, req *dto.ChallengeRequest) (*dto.UpdateChallengeResponse, error) {
+	if err := validateChallengeRequest(req); err != nil {
 		return nil, err
 	}
-	return chal, nil
+
+	var warnings []string
+	var chal models.Challenge
+	imageChanged, servicesChanged := false, false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockChallenge(tx, id, &chal); err != nil {
+			return err
+		}
+		before := chal
+
+		if chal.SolvedCount > 0 && chal.Mode == "static" && !equalStringPtr(chal.StaticFlag, req.StaticFlag) {
+			warnings = append(warnings, fmt.Sprintf("该题已被 %d 支队伍解出，修改 Flag 不影响已有解题记录", chal.SolvedCount))
+		}
+
+		chal.ChallengeName = req.ChallengeName
+		chal.Direction = req.Direction
+		chal.Author = req.Author
+		chal.Description = req.Description
+		chal.Hint = req.Hint
+		chal.State = req.State
+		chal.Mode = req.Mode
+		chal.StaticFlag = req.StaticFlag
+		imageChanged = !equalStringPtr(chal.DockerImage, req.DockerImage)
+		chal.DockerImage = req.DockerImage
+		chal.DockerPorts = models.DockerPorts(req.DockerPorts)
+		specs := newServiceSpecs(req.Services)
+		servicesChanged = !equalServiceSpecs(chal.Services, specs)
+		chal.Services = specs
+		chal.WarmPoolSize = req.WarmPoolSize
+		chal.Difficulty = req.Difficulty
+		chal.InitialScore = req.InitialScore
+		chal.MinScore = req.MinScore
+		chal.DecayRatio = req.DecayRatio
+		chal.ReleaseAt = req.ReleaseAt
+		chal.ReleaseNotice = req.ReleaseNotice
+		chal.Tracks = models.ChallengeTracks(req.Tracks)
+		chal.CurrentScore = chal.CalculateCurrentScore()
+
+		if err := tx.Model(&chal).Select("challenge_name", "direction", "author", "description", "hint",
+			"state", "mode", "static_flag", "docker_image", "docker_ports", "services", "warm_pool_size", "difficulty", "initial_score",
+			"min_score", "decay_ratio", "current_score", "release_at", "release_notice", "tracks").
+			Updates(&chal).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&chal, id).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditChallengeUpdate, "challenge", id, &before, &chal)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if imageChanged || servicesChanged {
+		pullChallengeImage(&chal)
+	}
+	if servicesChanged {
+		// 预热容器按旧的服务定义创建，全部销毁后由预热池重新补充
+		go s.drainWarmPool(chal.ID)
+	}
+
+	return &dto.UpdateChallengeResponse{
+		AdminChallengeResponse: newAdminChallengeResponse(&chal),
+		Warnings:               warnings,
+	}, nil
+}
+
+// UpdateChallengeState 修改题目可见状态（管理员）
+func (s *ChallengeService) UpdateChallengeState(op *Operator, id int64, state string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var chal models.Challenge
+		if err := s.lockChallenge(tx, id, &chal); err != nil {
+			return err
+		}
+		before := map[string]interface{}{"state": chal.State}
+		if err := tx.Model(&chal).UpdateColumn("state", state).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditChallengeState, "challenge", id, before, map[string]interface{}{"state": state})
+	})
+}
+
+// DeleteChallenge 删除题目（软删除），已有解题记录与团队分数保持不变
+func (s *ChallengeService) DeleteChallenge(op *Operator, id int64) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var chal models.Challenge
+		if err := s.lockChallenge(tx, id, &chal); err != nil {
+			return err
+		}
+		before := chal
+		if err := tx.Model(&chal).UpdateColumn("deleted_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditChallengeDelete, "challenge", id, &before, nil)
+	})
+}
+
+// lockChallenge 加行锁获取未删除的题目
+func (s *ChallengeService) lockChallenge(tx *gorm.DB, id int64, chal *models.Challenge) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("deleted_at IS NULL").
+		First(chal, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("题目不存在")
+		}
+		return err
+	}
+	return nil
+}
+
+// validateChallengeRequest 校验题目模式所需字段
+func validateChallengeRequest(req *dto.ChallengeRequest) error {
+	if err := applyServiceSpecs(req); err != nil {
+		return err
+	}
+	if req.Mode == "static" && (req.StaticFlag == nil || strings.TrimSpace(*req.StaticFlag) == "") {
+		return errors.New("静态题目必须设置 Flag")
+	}
+	if req.Mode == "dynamic" && (req.DockerImage == nil || strings.TrimSpace(*req.DockerImage) == "") {
+		return errors.New("动态题目必须设置 Docker 镜像")
+	}
+	if req.MinScore > req.InitialScore {
+		return errors.New("最低分值不能高于初始分值")
+	}
+	return nil
+}
+
+// newChallengeResponse 转换为选手侧题目响应
+func newChallengeResponse(chal *models.Challenge) dto.ChallengeResponse {
+	tracks := []string(chal.Tracks)
+	if tracks == nil {
+		tracks = []string{}
+	}
+	return dto.ChallengeResponse{
+		ID:            chal.ID,
+		ChallengeName: chal.ChallengeName,
+		Direction:     chal.Direction,
+		Author:        chal.Author,
+		Description:   chal.Description,
+		Hint:          chal.Hint,
+		Mode:          chal.Mode,
+		Difficulty:    chal.Difficulty,
+		InitialScore:  chal.InitialScore,
+		CurrentScore:  chal.CurrentScore,
+		SolvedCount:   chal.SolvedCount,
+		Tracks:        tracks,
+		CreatedAt:     chal.CreatedAt,
+	}
+}
+
+// newAdminChallengeResponse 转换为管理端题目响应
+func newAdminChallengeResponse(chal *models.Challenge) dto.AdminChallengeResponse {
+	return dto.AdminChallengeResponse{
+		ChallengeResponse: newChallengeResponse(chal),
+		State:             chal.State,
+		StaticFlag:        chal.StaticFlag,
+		DockerImage:       chal.DockerImage,
+		DockerPorts:       map[string]string(chal.DockerPorts),
+		Services:          newServiceSpecResponses(chal.Services),
+		WarmPoolSize:      chal.WarmPoolSize,
+		MinScore:          chal.MinScore,
+		DecayRatio:        chal.DecayRatio,
+		UnlockScore:       chal.UnlockScore,
+		ReleaseAt:         chal.ReleaseAt,
+		ReleaseNotice:     chal.ReleaseNotice,
+		CreatedBy:         chal.CreatedBy,
+		UpdatedAt:         chal.UpdatedAt,
+	}
+}
+
+// equalStringPtr 比较两个可空字符串是否相同
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 // GetChallengeList 获取列表
-func (s *ChallengeService) GetChallengeList(req *dto.ChallengeListRequest, isAdmin bool) (interface{}, int64, error) {
-	var list []models.Challenge
-	var total int64
-	db := config.DB.Model(&models.Challenge{})
+// 只返回已满足解锁条件的题目，teamID 为 0 表示未登录或未加入团队
+func (s *ChallengeService) GetChallengeList(req *dto.ChallengeListRequest, teamID int64) ([]dto.ChallengeResponse, int64, error) {
+	// 未到放出时间的题目对选手完全不可见（包括搜索）
+	db := config.DB.Model(&models.Challenge{}).
+		Where("deleted_at IS NULL AND state = ?", "visible").
+		Where("release_at IS NULL OR release_at <= ?", time.Now())
+
+	lockedIDs, err := NewPrerequisiteService().LockedChallengeIDs(teamID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(lockedIDs) > 0 {
+		db = db.Where("id NOT IN ?", lockedIDs)
+	}
 
-	if !isAdmin {
-		db = db.Where("state = ?", "visible")
-	} else if req.State != "" {
+	// 限定赛道的题目仅对对应赛道团队可见
+	track, err := s.teamTrack(teamID)
+	if err != nil {
+		return nil, 0, err
+	}
+	db = s.scopeTrack(db, track)
+
+	list, total, err := s.findChallenges(db, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]dto.ChallengeResponse, 0, len(list))
+	for i := range list {
+		resp = append(resp, newChallengeResponse(&list[i]))
+	}
+	return resp, total, nil
+}
+
+// GetAdminChallengeList 管理端获取题目列表，包含隐藏及未放出的题目
+// createdBy 非 0 时只返回该用户创建的题目（出题人）
+func (s *ChallengeService) GetAdminChallengeList(req *dto.ChallengeListRequest, createdBy int64) ([]dto.AdminChallengeResponse, int64, error) {
+	db := config.DB.Model(&models.Challenge{}).Where("deleted_at IS NULL")
+	if createdBy != 0 {
+		db = db.Where("created_by = ?", createdBy)
+	}
+	if req.State != "" {
 		db = db.Where("state = ?", req.State)
 	}
+	if req.Track != "" {
+		db = s.scopeTrack(db, req.Track)
+	}
+
+	list, total, err := s.findChallenges(db, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]dto.AdminChallengeResponse, 0, len(list))
+	for i := range list {
+		resp = append(resp, newAdminChallengeResponse(&list[i]))
+	}
+	return resp, total, nil
+}
+
+// findChallenges 按方向、难度、名称筛选并分页查询题目
+func (s *ChallengeService) findChallenges(db *gorm.DB, req *dto.ChallengeListRequest) ([]models.Challenge, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
 
 	if req.Direction != "" {
 		db = db.Where("direction = ?", req.Direction)
@@ -70,85 +330,379 @@ func (s *ChallengeService) GetChallengeList(req *dto.ChallengeListRequest, isAdm
 		db = db.Where("challenge_name LIKE ?", "%"+req.Search+"%")
 	}
 
-	db.Count(&total)
-	err := db.Order("id DESC").Offset((req.Page - 1) * req.Limit).Limit(req.Limit).Find(&list).Error
-	return list, total, err
+	var list []models.Challenge
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Order("id DESC").Offset((req.Page - 1) * req.Limit).Limit(req.Limit).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
 }
 
-// GetDetail 获取详情
-func (s *ChallengeService) GetDetail(id int64, isAdmin bool) (*models.Challenge, error) {
+// GetDetail 获取详情（仅可见且已满足解锁条件的题目）
+func (s *ChallengeService) GetDetail(id int64, teamID int64) (*dto.ChallengeResponse, error) {
 	var chal models.Challenge
-	db := config.DB
-	if !isAdmin {
-		db = db.Where("state = ?", "visible")
-	}
-	if err := db.First(&chal, id).Error; err != nil {
+	if err := config.DB.
+		Where("deleted_at IS NULL AND state = ?", "visible").
+		Where("release_at IS NULL OR release_at <= ?", time.Now()).
+		First(&chal, id).Error; err != nil {
 		return nil, errors.New("题目不存在或不可见")
 	}
-	return &chal, nil
+	if err := s.CheckChallengeAccess(teamID, &chal); err != nil {
+		return nil, err
+	}
+	resp := newChallengeResponse(&chal)
+	return &resp, nil
+}
+
+// GetAdminDetail 管理端获取题目详情
+func (s *ChallengeService) GetAdminDetail(id int64) (*dto.AdminChallengeResponse, error) {
+	var chal models.Challenge
+	if err := config.DB.Where("deleted_at IS NULL").First(&chal, id).Error; err != nil {
+		return nil, errors.New("题目不存在")
+	}
+	resp := newAdminChallengeResponse(&chal)
+	return &resp, nil
+}
+
+// CheckChallengeAccess 检查团队能否访问题目（可见且已满足解锁条件）
+func (s *ChallengeService) CheckChallengeAccess(teamID int64, chal *models.Challenge) error {
+	if !chal.IsVisible() {
+		return errors.New("题目不存在或不可见")
+	}
+	if chal.IsRestricted() {
+		track, err := s.teamTrack(teamID)
+		if err != nil {
+			return err
+		}
+		if !chal.AllowsTrack(track) {
+			return errors.New("题目不存在或不可见")
+		}
+	}
+	unlocked, err := NewPrerequisiteService().IsUnlocked(teamID, chal)
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return errors.New("题目尚未解锁")
+	}
+	return nil
+}
+
+// teamTrack 获取团队赛道，teamID 为 0 时返回空字符串
+func (s *ChallengeService) teamTrack(teamID int64) (string, error) {
+	if teamID == 0 {
+		return "", nil
+	}
+	var team models.Team
+	if err := config.DB.Select("id, team_track").First(&team, teamID).Error; err != nil {
+		return "", err
+	}
+	return team.TeamTrack, nil
+}
+
+// scopeTrack 筛选指定赛道可访问的题目，track 为空时仅保留不限赛道的题目
+func (s *ChallengeService) scopeTrack(db *gorm.DB, track string) *gorm.DB {
+	if track == "" {
+		return db.Where("tracks = ''")
+	}
+	return db.Where("tracks = '' OR FIND_IN_SET(?, tracks) > 0", track)
+}
+
+// ScheduleRelease 设置题目定时放出（管理员）
+func (s *ChallengeService) ScheduleRelease(op *Operator, id int64, req *dto.ScheduleReleaseRequest) (*dto.AdminChallengeResponse, error) {
+	var chal models.Challenge
+	if err := config.DB.Where("deleted_at IS NULL").First(&chal, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("题目不存在")
+		}
+		return nil, err
+	}
+
+	if req.ReleaseAt != nil && !req.ReleaseAt.After(time.Now()) {
+		return nil, errors.New("放出时间必须晚于当前时间")
+	}
+
+	before := map[string]interface{}{"release_at": chal.ReleaseAt, "release_notice": chal.ReleaseNotice}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&chal).Updates(map[string]interface{}{
+			"release_at":     req.ReleaseAt,
+			"release_notice": req.ReleaseNotice,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&chal, id).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditChallengeRelease, "challenge", id, before,
+			map[string]interface{}{"release_at": chal.ReleaseAt, "release_notice": chal.ReleaseNotice})
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := newAdminChallengeResponse(&chal)
+	return &resp, nil
+}
+
+// GetUpcomingReleases 获取待放出的题目列表（管理员），按放出时间升序
+func (s *ChallengeService) GetUpcomingReleases() ([]dto.ChallengeReleaseResponse, error) {
+	var chals []models.Challenge
+	if err := config.DB.Where("release_at IS NOT NULL AND deleted_at IS NULL").
+		Order("release_at ASC, id ASC").
+		Find(&chals).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.ChallengeReleaseResponse, 0, len(chals))
+	for _, c := range chals {
+		list = append(list, dto.ChallengeReleaseResponse{
+			ID:            c.ID,
+			ChallengeName: c.ChallengeName,
+			Direction:     c.Direction,
+			Difficulty:    c.Difficulty,
+			State:         c.State,
+			ReleaseAt:     *c.ReleaseAt,
+			ReleaseNotice: c.ReleaseNotice,
+		})
+	}
+	return list, nil
+}
+
+// ReleaseDueChallenges 放出已到时间的题目，返回本次放出的题目数量
+// 放出后清空 release_at，之后题目状态由管理员手动控制
+// 每道题目的状态更新与放出公告在同一事务中完成，单道题目失败时记录日志并在下一轮重试
+func (s *ChallengeService) ReleaseDueChallenges() (int, error) {
+	now := time.Now()
+	var chals []models.Challenge
+	if err := config.DB.Where("release_at IS NOT NULL AND release_at <= ? AND deleted_at IS NULL", now).
+		Order("release_at ASC, id ASC").
+		Find(&chals).Error; err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for i := range chals {
+		chal := &chals[i]
+		done := false
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			// 条件更新，防止多实例部署时重复放出
+			result := tx.Model(&models.Challenge{}).
+				Where("id = ? AND release_at IS NOT NULL AND release_at <= ?", chal.ID, now).
+				Updates(map[string]interface{}{
+					"state":      "visible",
+					"release_at": nil,
+				})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			done = true
+
+			if chal.ReleaseNotice {
+				challengeID := chal.ID
+				// 系统自动发布，创建者记为 0
+				if _, err := NewNoticeService().createNotice(tx, nil, &dto.NoticeRequest{
+					Title:       fmt.Sprintf("新题目上线：%s", chal.ChallengeName),
+					Content:     fmt.Sprintf("%s 方向题目「%s」已放出，祝各位选手解题顺利！", chal.Direction, chal.ChallengeName),
+					ChallengeID: &challengeID,
+				}, "published"); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("放出题目 %d 失败: %v\n", chal.ID, err)
+			continue
+		}
+		if done {
+			released++
+		}
+	}
+	return released, nil
 }
 
 // StartContainer 启动动态题目容器
-func (s *ChallengeService) StartContainer(userID, teamID, challengeID int64) (*models.Container, error) {
+// 团队运行中的容器达到上限时返回 ErrContainerTeamLimit；
+// 全局容量已满时，queue 为 true 则加入排队并返回排队记录，否则返回 ErrContainerCapacityFull
+func (s *ChallengeService) StartContainer(userID, teamID, challengeID int64, queue bool) (*models.Container, *dto.ContainerQueueResponse, error) {
 	// 1. 检查题目
 	var chal models.Challenge
 	if err := config.DB.First(&chal, challengeID).Error; err != nil {
-		return nil, errors.New("题目不存在")
+		return nil, nil, errors.New("题目不存在")
 	}
 	if chal.Mode != "dynamic" {
-		return nil, errors.New("非动态题目无需启动容器")
+		return nil, nil, errors.New("非动态题目无需启动容器")
 	}
-	if chal.State != "visible" {
-		return nil, errors.New("题目不可见")
+	if err := s.CheckChallengeAccess(teamID, &chal); err != nil {
+		return nil, nil, err
 	}
 
 	// 2. 检查是否已有运行中的容器
+	if existing, err := s.runningContainer(teamID, challengeID); err != nil || existing != nil {
+		return existing, nil, err
+	}
+
+	// 3. 已在排队中则直接返回排队记录
+	entry, err := s.activeQueueEntry(teamID, challengeID)
+	if err != nil || entry != nil {
+		return nil, entry, err
+	}
+
+	// 4. 检查团队配额并占用全局容量名额
+	slot, queued, err := s.reserveContainerSlot(userID, teamID, challengeID, queue)
+	if err != nil {
+		return nil, nil, err
+	}
+	if queued != nil {
+		entry, err := s.queueResponse(queued)
+		return nil, entry, err
+	}
+
+	c, err := s.launchReserved(userID, teamID, &chal, slot)
+	return c, nil, err
+}
+
+// runningContainer 查询团队在该题目上运行中的容器，已过期的先销毁
+func (s *ChallengeService) runningContainer(teamID, challengeID int64) (*models.Container, error) {
 	var existing models.Container
 	err := config.DB.Where("team_id = ? AND challenge_id = ? AND state = 'running'", teamID, challengeID).First(&existing).Error
-	if err == nil {
-		// 已存在
-		if time.Now().Before(existing.EndTime) {
-			return &existing, nil
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
 		}
-		// 已过期但状态未更新，先停止
-		_ = s.stopContainerInternal(&existing)
+		return nil, err
+	}
+	if time.Now().Before(existing.EndTime) {
+		return &existing, nil
 	}
+	// 已过期但状态未更新，先停止
+	_ = s.stopContainerInternal(&existing)
+	return nil, nil
+}
 
-	// 3. 生成 Flag
-	flag := utils.GenerateDynamicFlag(teamID, challengeID)
+// launchContainer 生成 Flag 并为团队分配容器，优先使用预热容器，调用方负责配额检查
+func (s *ChallengeService) launchContainer(userID, teamID int64, chal *models.Challenge) (*models.Container, error) {
+	// 生成 Flag
+	flag := utils.GenerateDynamicFlag(teamID, chal.ID)
+
+	if c := s.claimWarmContainer(userID, teamID, chal, flag); c != nil {
+		return c, nil
+	}
 
-	// 4. 调用 Docker API
 	// 环境变量注入 Flag
 	env := []string{
 		fmt.Sprintf("FLAG=%s", flag),
 		fmt.Sprintf("GZCTF_FLAG=%s", flag), // 兼容常见CTF镜像
 	}
+	c, err := s.createContainer(chal, userID, teamID, flag, env, "running")
+	if err != nil {
+		return nil, err
+	}
 
-	containerID, hostMapping, err := utils.StartContainer(*chal.DockerImage, chal.DockerPorts, env)
+	// 启用预热池的镜像从文件读取 Flag，冷启动时同样写入
+	if chal.WarmPoolSize > 0 {
+		if err := utils.WriteContainerFile(c.ContainerName, warmFlagPath, []byte(flag)); err != nil {
+			_ = s.stopContainerInternal(c)
+			return nil, fmt.Errorf("写入 Flag 失败: %v", err)
+		}
+	}
+	return c, nil
+}
+
+// createContainer 分配端口、创建 Docker 容器并写入容器记录
+// state 为 warm 时创建未分配的预热容器，不注入 Flag、不设置团队
+func (s *ChallengeService) createContainer(chal *models.Challenge, userID, teamID int64, flag string, env []string, state string) (*models.Container, error) {
+	challengeID := chal.ID
+
+	// 从端口池分配宿主机端口，每个容器端口一个
+	portPool := NewPortPoolService()
+	containerPorts := make([]string, 0, len(chal.DockerPorts))
+	for port := range chal.DockerPorts {
+		containerPorts = append(containerPorts, port)
+	}
+	sort.Strings(containerPorts)
+	leased, err := portPool.Allocate(teamID, challengeID, len(containerPorts))
 	if err != nil {
+		return nil, err
+	}
+	hostPorts := make(map[string]int, len(containerPorts))
+	for i, port := range containerPorts {
+		hostPorts[port] = leased[i]
+	}
+
+	labels := map[string]string{
+		utils.ContainerLabelChallenge: strconv.FormatInt(challengeID, 10),
+	}
+	if teamID != 0 {
+		labels[utils.ContainerLabelTeam] = strconv.FormatInt(teamID, 10)
+	}
+	// 多容器题目在私有网络中启动整组服务，失败时由 startServiceGroup 回滚
+	var containerID, networkID string
+	var members models.ServiceIDs
+	var hostMapping map[string]string
+	if len(chal.Services) > 0 {
+		containerID, networkID, members, hostMapping, err = startServiceGroup(chal, hostPorts, env, labels)
+	} else {
+		protocols, hostIPs := dockerPortOptions(chal.DockerPorts)
+		containerID, hostMapping, err = utils.StartContainer(utils.ContainerOptions{
+			Image:     *chal.DockerImage,
+			Ports:     protocols,
+			HostPorts: hostPorts,
+			HostIP:    config.AppConfig.Container.BindIP,
+			HostIPs:   hostIPs,
+			Env:       env,
+			Labels:    labels,
+		})
+	}
+	if err != nil {
+		if containerID != "" {
+			_ = utils.RemoveContainer(containerID)
+		}
+		_ = portPool.ReleasePorts(leased)
 		return nil, fmt.Errorf("启动容器失败: %v", err)
 	}
 
-	// 5. 记录数据库
+	// 记录数据库
 	// 注意：Docker 返回的 containerID 是长 ID
 	newContainer := &models.Container{
 		ChallengeID:   challengeID,
 		TeamID:        teamID,
 		UserID:        userID,
 		ContainerName: containerID, // 存储 Docker ID
+		NetworkID:     networkID,
+		Members:       members,
 		DockerImage:   *chal.DockerImage,
 		DockerPorts:   chal.DockerPorts,
 		HostMapping:   models.PortMapping(hostMapping),
 		ContainerFlag: flag,
-		State:         "running",
+		State:         state,
 		StartTime:     time.Now(),
 		EndTime:       time.Now().Add(1 * time.Hour), // 默认1小时
 		ExtendedCount: 0,
 	}
 
+	// 反向代理子域名令牌
+	proxyToken, err := randomProxyToken()
+	if err != nil {
+		s.rollbackDockerContainers(newContainer)
+		_ = portPool.ReleasePorts(leased)
+		return nil, err
+	}
+	newContainer.ProxyToken = proxyToken
+
 	if err := config.DB.Create(newContainer).Error; err != nil {
 		// 数据库插入失败，回滚Docker操作
-		_ = utils.RemoveContainer(containerID)
+		s.rollbackDockerContainers(newContainer)
+		_ = portPool.ReleasePorts(leased)
+		return nil, err
+	}
+	if err := portPool.Attach(leased, newContainer.ID); err != nil {
+		// 租约已被回收时端口可能重复分配，销毁容器并释放已关联的端口
+		if stopErr := s.stopContainerInternal(newContainer); stopErr != nil {
+			fmt.Printf("销毁容器 %d 失败: %v\n", newContainer.ID, stopErr)
+		}
 		return nil, err
 	}
 
@@ -168,13 +722,37 @@ func (s *ChallengeService) StopChallengeContainer(userID, challengeID int64) err
 	return s.stopContainerInternal(&c)
 }
 
+// 先标记为 stopped 停止对外服务，Docker 资源全部删除后再标记为 destroyed
 func (s *ChallengeService) stopContainerInternal(c *models.Container) error {
-	// 调用 Docker API
-	utils.RemoveContainer(c.ContainerName)
+	c.State = "stopped"
+	if err := config.DB.Save(c).Error; err != nil {
+		return err
+	}
+	NewContainerProxyService().InvalidateTarget(c.ProxyToken)
+
+	return s.destroyStoppedContainer(c)
+}
+
+// destroyStoppedContainer 删除已停止容器的全部 Docker 容器及私有网络，完成后标记为 destroyed 并释放容量名额与宿主机端口
+// 删除未完成时保留 stopped 状态、容量名额与端口租约，由容器状态同步任务重试
+func (s *ChallengeService) destroyStoppedContainer(c *models.Container) error {
+	if err := removeDockerContainers(c); err != nil {
+		return fmt.Errorf("容器删除未完成，稍后自动重试: %v", err)
+	}
+	if err := config.DB.Model(c).Update("state", "destroyed").Error; err != nil {
+		return err
+	}
+	if err := NewContainerSlotService().Release(c.ID); err != nil {
+		return err
+	}
+	return NewPortPoolService().Release(c.ID)
+}
 
-	// 更新数据库
-	c.State = "destroyed"
-	return config.DB.Save(c).Error
+// rollbackDockerContainers 回滚尚未写入数据库的容器，残留部分由容器状态同步任务按孤儿容器清理
+func (s *ChallengeService) rollbackDockerContainers(c *models.Container) {
+	if err := removeDockerContainers(c); err != nil {
+		fmt.Printf("回滚容器失败: %v\n", err)
+	}
 }
 
 // SubmitFlag 提交 Flag
@@ -184,6 +762,9 @@ func (s *ChallengeService) SubmitFlag(userID, teamID, challengeID int64, flag st
 	if err := config.DB.First(&chal, challengeID).Error; err != nil {
 		return false, 0, errors.New("题目不存在")
 	}
+	if err := s.CheckChallengeAccess(teamID, &chal); err != nil {
+		return false, 0, err
+	}
 
 	// 2. 检查是否已解出
 	var solveCount int64
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"isctf/config"
+	"isctf/models"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrGatewayTeamLimit 团队 TCP 中转连接数已达上限
+var ErrGatewayTeamLimit = errors.New("团队 TCP 中转连接数已达上限，请先关闭其他连接")
+
+// gatewayDialTimeout 连接容器端口的超时时间
+const gatewayDialTimeout = 5 * time.Second
+
+// gatewayConns 各团队当前的中转连接数（按实例统计）
+var gatewayConns struct {
+	sync.Mutex
+	teams map[int64]int
+}
+
+// GatewayTarget TCP 中转目标
+type GatewayTarget struct {
+	ContainerID int64
+	TeamID      int64
+	Addr        string    // 宿主机地址:端口
+	EndTime     time.Time // 容器到期时间，到期后连接自动断开
+}
+
+// ContainerGatewayService 容器 TCP-over-WebSocket 中转服务
+type ContainerGatewayService struct{}
+
+// NewContainerGatewayService 创建 TCP 中转服务实例
+func NewContainerGatewayService() *ContainerGatewayService {
+	return &ContainerGatewayService{}
+}
+
+// Resolve 校验容器归属并解析中转目标，port 为容器内部端口，为空时取端口号最小的 TCP 端口
+func (s *ContainerGatewayService) Resolve(teamID, containerID int64, port string) (*GatewayTarget, error) {
+	var c models.Container
+	if err := config.DB.Where("id = ? AND state = 'running' AND deleted_at IS NULL", containerID).First(&c).Error; err != nil {
+		return nil, errors.New("未找到运行中的容器")
+	}
+	if c.TeamID != teamID {
+		return nil, errors.New("无权访问该容器")
+	}
+	if !time.Now().Before(c.EndTime) {
+		return nil, errors.New("容器已到期")
+	}
+	hostPort := gatewayHostPort(&c, port)
+	if hostPort == "" {
+		return nil, errors.New("容器未开放该 TCP 端口")
+	}
+
+	return &GatewayTarget{
+		ContainerID: c.ID,
+		TeamID:      c.TeamID,
+		Addr:        net.JoinHostPort(gatewayUpstreamHost(), hostPort),
+		EndTime:     c.EndTime,
+	}, nil
+}
+
+// gatewayHostPort 获取容器 tcp 端口映射到的宿主机端口，http 端口经由反向代理访问，不在此列
+// port 为空时选取端口号最小的 tcp 端口，未映射时返回空字符串
+func gatewayHostPort(c *models.Container, port string) string {
+	if port == "" {
+		return lowestHostPort(c, isGatewayPort)
+	}
+	if !isGatewayPort(c.DockerPorts[port]) {
+		return ""
+	}
+	return c.HostMapping[port+"/tcp"]
+}
+
+// isGatewayPort 可通过 TCP 中转访问的端口协议
+func isGatewayPort(proto string) bool {
+	return proto == "tcp"
+}
+
+// gatewayUpstreamHost TCP 中转连接容器使用的宿主机地址，tcp 端口始终绑定在 BindIP 上
+func gatewayUpstreamHost() string {
+	bindIP := config.AppConfig.Container.BindIP
+	if bindIP == "" || bindIP == "0.0.0.0" {
+		return "127.0.0.1"
+	}
+	return bindIP
+}
+
+// Acquire 占用团队的一个中转连接名额，返回的 release 需在连接关闭后调用
+func (s *ContainerGatewayService) Acquire(teamID int64) (release func(), err error) {
+	gatewayConns.Lock()
+	defer gatewayConns.Unlock()
+
+	if gatewayConns.teams == nil {
+		gatewayConns.teams = make(map[int64]int)
+	}
+	if gatewayConns.teams[teamID] >= config.AppConfig.Container.GatewayTeamLimit {
+		return nil, ErrGatewayTeamLimit
+	}
+	gatewayConns.teams[teamID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			gatewayConns.Lock()
+			defer gatewayConns.Unlock()
+			if gatewayConns.teams[teamID]--; gatewayConns.teams[teamID] <= 0 {
+				delete(gatewayConns.teams, teamID)
+			}
+		})
+	}, nil
+}
+
+// Relay 在 WebSocket 与容器 TCP 端口之间双向转发数据，直到任一方关闭、空闲超时或容器到期
+// WebSocket 方向使用二进制消息承载原始字节流
+func (s *ContainerGatewayService) Relay(ws *websocket.Conn, target *GatewayTarget) error {
+	conn, err := net.DialTimeout("tcp", target.Addr, gatewayDialTimeout)
+	if err != nil {
+		_ = ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "连接容器失败"),
+			time.Now().Add(time.Second))
+		return fmt.Errorf("连接容器失败: %v", err)
+	}
+
+	idle := time.Duration(config.AppConfig.Container.GatewayIdleTimeout) * time.Second
+	var lastActive atomic.Int64
+	lastActive.Store(time.Now().UnixNano())
+	done := make(chan struct{})
+	var closeOnce sync.Once
+	closeAll := func(reason string) {
+		closeOnce.Do(func() {
+			_ = ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason),
+				time.Now().Add(time.Second))
+			ws.Close()
+			conn.Close()
+			close(done)
+		})
+	}
+
+	// WebSocket -> TCP
+	go func() {
+		for {
+			msgType, data, err := ws.ReadMessage()
+			if err != nil {
+				closeAll("")
+				return
+			}
+			if msgType != websocket.BinaryMessage && msgType != websocket.TextMessage {
+				continue
+			}
+			lastActive.Store(time.Now().UnixNano())
+			if _, err := conn.Write(data); err != nil {
+				closeAll("容器连接已断开")
+				return
+			}
+		}
+	}()
+
+	// TCP -> WebSocket
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				lastActive.Store(time.Now().UnixNano())
+				if werr := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+					closeAll("")
+					return
+				}
+			}
+			if err != nil {
+				closeAll("容器连接已断开")
+				return
+			}
+		}
+	}()
+
+	// 空闲与到期检查
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case now := <-ticker.C:
+			if now.Sub(time.Unix(0, lastActive.Load())) > idle {
+				closeAll("空闲超时")
+				return nil
+			}
+			if !now.Before(target.EndTime) {
+				closeAll("容器已到期")
+				return nil
+			}
+		}
+	}
+}
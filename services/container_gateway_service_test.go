@@ -0,0 +1,84 @@
+package services
+
+import (
+	"isctf/config"
+	"isctf/models"
+	"testing"
+	"time"
+)
+
+// setupGatewayTest 准备一个同时声明 tcp 与 http 端口的运行中容器，反向代理开启鉴权
+func setupGatewayTest(t *testing.T, bindIP string) *models.Container {
+	t.Helper()
+	oldConfig := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = oldConfig })
+	config.AppConfig = &config.Config{
+		Server: config.ServerConfig{Mode: "test"},
+		Container: config.ContainerConfig{
+			BindIP:           bindIP,
+			ProxyDomain:      "chal.test",
+			ProxyRequireAuth: true,
+		},
+	}
+
+	db := setupTestDB(t, &models.Container{})
+	c := &models.Container{
+		ChallengeID:   1,
+		TeamID:        1,
+		UserID:        1,
+		ContainerName: "entry",
+		DockerImage:   "isctf/test",
+		DockerPorts:   models.DockerPorts{"9999": "tcp", "80": "http"},
+		HostMapping:   models.PortMapping{"9999/tcp": "30001", "80/tcp": "30002"},
+		ContainerFlag: "flag{test}",
+		State:         "running",
+		EndTime:       time.Now().Add(time.Hour),
+	}
+	if err := db.Create(c).Error; err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestGatewayResolveTCPPort(t *testing.T) {
+	c := setupGatewayTest(t, "10.0.0.5")
+	svc := NewContainerGatewayService()
+
+	// tcp 端口绑定在 BindIP 上，开启代理鉴权时也不能回落到回环地址
+	for _, port := range []string{"", "9999"} {
+		target, err := svc.Resolve(1, c.ID, port)
+		if err != nil {
+			t.Fatalf("Resolve(port=%q): %v", port, err)
+		}
+		if target.Addr != "10.0.0.5:30001" {
+			t.Fatalf("Resolve(port=%q) addr = %s, want 10.0.0.5:30001", port, target.Addr)
+		}
+	}
+
+	// http 端口只经由反向代理访问
+	if _, err := svc.Resolve(1, c.ID, "80"); err == nil {
+		t.Fatal("http 端口不应允许 TCP 中转")
+	}
+	if _, err := svc.Resolve(2, c.ID, ""); err == nil {
+		t.Fatal("其他团队不应访问该容器")
+	}
+
+	if got := proxyHostPort(c); got != "30002" {
+		t.Fatalf("proxyHostPort = %s, want 30002", got)
+	}
+	if got := proxyUpstreamHost(); got != "127.0.0.1" {
+		t.Fatalf("proxyUpstreamHost = %s, want 127.0.0.1", got)
+	}
+}
+
+func TestGatewayResolveWildcardBindIP(t *testing.T) {
+	c := setupGatewayTest(t, "0.0.0.0")
+
+	target, err := NewContainerGatewayService().Resolve(1, c.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Addr != "127.0.0.1:30001" {
+		t.Fatalf("addr = %s, want 127.0.0.1:30001", target.Addr)
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"isctf/utils"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// ErrInvalidServiceSpec 多容器服务定义不合法
+var ErrInvalidServiceSpec = errors.New("多容器服务配置无效")
+
+// serviceNamePattern 服务名与网络别名需为合法的 DNS 标签
+var serviceNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// applyServiceSpecs 校验多容器服务定义，并以第一个服务为入口推导 docker_image / docker_ports
+// 各服务暴露的端口不能重复，以便沿用按容器端口索引的宿主机端口映射
+func applyServiceSpecs(req *dto.ChallengeRequest) error {
+	if req.Mode != "dynamic" {
+		req.Services = nil
+		return nil
+	}
+	if len(req.Services) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	ports := make(map[string]string)
+	for _, svc := range req.Services {
+		for _, name := range append([]string{svc.Name}, svc.Aliases...) {
+			if !serviceNamePattern.MatchString(name) {
+				return fmt.Errorf("%w：名称 %q 只能包含小写字母、数字和连字符", ErrInvalidServiceSpec, name)
+			}
+			if names[name] {
+				return fmt.Errorf("%w：名称 %q 重复", ErrInvalidServiceSpec, name)
+			}
+			names[name] = true
+		}
+		for port, proto := range svc.Ports {
+			if _, ok := ports[port]; ok {
+				return fmt.Errorf("%w：端口 %s 被多个服务暴露", ErrInvalidServiceSpec, port)
+			}
+			ports[port] = proto
+		}
+	}
+
+	image := req.Services[0].Image
+	req.DockerImage = &image
+	req.DockerPorts = ports
+	return nil
+}
+
+// newServiceSpecs 转换为模型中的服务定义
+func newServiceSpecs(specs []dto.ServiceSpec) models.ServiceSpecs {
+	if len(specs) == 0 {
+		return nil
+	}
+	result := make(models.ServiceSpecs, 0, len(specs))
+	for _, svc := range specs {
+		result = append(result, models.ServiceSpec{
+			Name:    svc.Name,
+			Image:   svc.Image,
+			Env:     svc.Env,
+			Aliases: svc.Aliases,
+			Ports:   models.DockerPorts(svc.Ports),
+		})
+	}
+	return result
+}
+
+// newServiceSpecResponses 转换为管理端响应中的服务定义
+func newServiceSpecResponses(specs models.ServiceSpecs) []dto.ServiceSpec {
+	result := make([]dto.ServiceSpec, 0, len(specs))
+	for _, svc := range specs {
+		result = append(result, dto.ServiceSpec{
+			Name:    svc.Name,
+			Image:   svc.Image,
+			Env:     svc.Env,
+			Aliases: svc.Aliases,
+			Ports:   map[string]string(svc.Ports),
+		})
+	}
+	return result
+}
+
+// equalServiceSpecs 比较两组服务定义是否相同
+func equalServiceSpecs(a, b models.ServiceSpecs) bool {
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	return bytes.Equal(aj, bj)
+}
+
+// challengeImages 返回题目需要的全部镜像
+func challengeImages(chal *models.Challenge) []string {
+	if len(chal.Services) > 0 {
+		images := make([]string, 0, len(chal.Services))
+		for _, svc := range chal.Services {
+			images = append(images, svc.Image)
+		}
+		return images
+	}
+	if chal.DockerImage == nil || *chal.DockerImage == "" {
+		return nil
+	}
+	return []string{*chal.DockerImage}
+}
+
+// startServiceGroup 为多容器题目创建私有网络并依次启动全部服务，任一服务失败时回滚整组
+// 返回入口服务的 Docker ID、网络 ID、全部服务容器及合并后的端口映射
+func startServiceGroup(chal *models.Challenge, hostPorts map[string]int, flagEnv []string, labels map[string]string) (string, string, models.ServiceIDs, map[string]string, error) {
+	suffix, err := randomProxyToken()
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+	networkName := fmt.Sprintf("isctf_%d_%s", chal.ID, suffix[:12])
+	networkID, err := utils.CreateNetwork(networkName, labels)
+	if err != nil {
+		return "", "", nil, nil, fmt.Errorf("创建私有网络失败: %v", err)
+	}
+
+	members := make(models.ServiceIDs, len(chal.Services))
+	hostMapping := make(map[string]string)
+	for _, svc := range chal.Services {
+		env := make([]string, 0, len(svc.Env)+len(flagEnv))
+		keys := make([]string, 0, len(svc.Env))
+		for k := range svc.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			env = append(env, fmt.Sprintf("%s=%s", k, svc.Env[k]))
+		}
+		env = append(env, flagEnv...)
+
+		protocols, hostIPs := dockerPortOptions(svc.Ports)
+		id, mapping, err := utils.StartContainer(utils.ContainerOptions{
+			Image:     svc.Image,
+			Ports:     protocols,
+			HostPorts: hostPorts,
+			HostIP:    config.AppConfig.Container.BindIP,
+			HostIPs:   hostIPs,
+			Env:       env,
+			Labels:    labels,
+			Network:   networkName,
+			Aliases:   append([]string{svc.Name}, svc.Aliases...),
+		})
+		if id != "" {
+			members[svc.Name] = id
+		}
+		if err != nil {
+			// 回滚未完成的部分由容器状态同步任务按孤儿容器及空私有网络清理
+			if rmErr := removeDockerContainers(&models.Container{Members: members, NetworkID: networkID}); rmErr != nil {
+				fmt.Printf("回滚题目 %d 服务组失败: %v\n", chal.ID, rmErr)
+			}
+			return "", "", nil, nil, fmt.Errorf("启动服务 %s 失败: %v", svc.Name, err)
+		}
+		for port, hostPort := range mapping {
+			hostMapping[port] = hostPort
+		}
+	}
+	return members[chal.Services[0].Name], networkID, members, hostMapping, nil
+}
+
+// dockerIDs 返回容器记录对应的全部 Docker 容器 ID
+func dockerIDs(c *models.Container) []string {
+	if len(c.Members) == 0 {
+		return []string{c.ContainerName}
+	}
+	ids := make([]string, 0, len(c.Members))
+	for _, id := range c.Members {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// removeDockerContainers 删除容器记录对应的全部 Docker 容器，多容器题目随后删除其私有网络
+// 已不存在的容器与网络视为删除成功；任一容器删除失败时保留网络（仍有容器接入时无法删除），返回全部错误
+func removeDockerContainers(c *models.Container) error {
+	var errs []error
+	for _, id := range dockerIDs(c) {
+		if id == "" {
+			continue
+		}
+		if err := utils.RemoveContainer(id); err != nil && !utils.IsContainerNotFound(err) {
+			errs = append(errs, fmt.Errorf("删除容器 %s 失败: %v", id, err))
+		}
+	}
+	if c.NetworkID != "" && len(errs) == 0 {
+		if err := utils.RemoveNetwork(c.NetworkID); err != nil && !utils.IsContainerNotFound(err) {
+			errs = append(errs, fmt.Errorf("删除私有网络 %s 失败: %v", c.NetworkID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lowestHostPort 在协议满足 match 的容器端口中，选取端口号最小且已映射的一个，返回其宿主机端口
+// 未找到时返回空字符串
+func lowestHostPort(c *models.Container, match func(proto string) bool) string {
+	ports := make([]int, 0, len(c.DockerPorts))
+	for port, proto := range c.DockerPorts {
+		if !match(proto) {
+			continue
+		}
+		if p, err := strconv.Atoi(port); err == nil {
+			ports = append(ports, p)
+		}
+	}
+	sort.Ints(ports)
+	for _, p := range ports {
+		if hostPort := c.HostMapping[strconv.Itoa(p)+"/tcp"]; hostPort != "" {
+			return hostPort
+		}
+	}
+	return ""
+}
+
+// groupDockerState 返回容器记录在 Docker 中的状态，多容器题目任一服务未运行即返回该服务的状态
+// 容器不存在时返回空字符串
+func groupDockerState(c *models.Container, dockerStates map[string]string) (string, error) {
+	for _, id := range dockerIDs(c) {
+		// 标签上线前创建的容器不在列表中，单独查询
+		state, ok := dockerStates[id]
+		if !ok {
+			var err error
+			state, err = utils.GetDockerContainerStatus(id)
+			if err != nil && !utils.IsContainerNotFound(err) {
+				return "", err
+			}
+		}
+		if state != "running" {
+			return state, nil
+		}
+	}
+	return "running", nil
+}
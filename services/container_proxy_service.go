@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"isctf/config"
+	"isctf/models"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// ProxyTicketParam 访问票据的查询参数名
+	ProxyTicketParam = "isctf_ticket"
+	// ProxySessionCookie 票据兑换后的会话 Cookie 名
+	ProxySessionCookie = "isctf_chal_session"
+
+	// proxyTicketTTL 访问票据有效期，票据只能使用一次
+	proxyTicketTTL = time.Minute
+	// proxyTargetTTL 子域名到容器地址的解析结果缓存时间
+	proxyTargetTTL = 10 * time.Second
+)
+
+// ProxyTarget 反向代理目标容器
+type ProxyTarget struct {
+	ContainerID int64     `json:"container_id"`
+	TeamID      int64     `json:"team_id"`
+	Addr        string    `json:"addr"` // 宿主机地址:端口
+	EndTime     time.Time `json:"end_time"`
+}
+
+// proxyGrant 票据或会话对应的授权信息
+type proxyGrant struct {
+	ContainerID int64     `json:"container_id"`
+	TeamID      int64     `json:"team_id"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"` // 会话到期时间，跟随容器到期时间延长
+}
+
+// ContainerProxyService 容器子域名反向代理服务
+type ContainerProxyService struct{}
+
+// NewContainerProxyService 创建容器反向代理服务实例
+func NewContainerProxyService() *ContainerProxyService {
+	return &ContainerProxyService{}
+}
+
+// Enabled 是否启用了反向代理
+func (s *ContainerProxyService) Enabled() bool {
+	return config.AppConfig.Container.ProxyDomain != ""
+}
+
+// ContainerURL 生成容器访问地址，未启用反向代理或容器没有声明为 http 的端口时返回空字符串
+// 开启访问鉴权时地址中附带一次性票据，选手打开后换取仅对该子域名有效的 Cookie
+func (s *ContainerProxyService) ContainerURL(c *models.Container) (string, error) {
+	if !s.Enabled() || c.ProxyToken == "" || proxyHostPort(c) == "" {
+		return "", nil
+	}
+
+	cfg := config.AppConfig.Container
+	u := url.URL{
+		Scheme: cfg.ProxyScheme,
+		Host:   c.ProxyToken + "." + cfg.ProxyDomain,
+		Path:   "/",
+	}
+	if cfg.ProxyRequireAuth {
+		ticket, err := randomProxyToken()
+		if err != nil {
+			return "", err
+		}
+		if err := s.setGrant("proxy_ticket:"+ticket, proxyGrant{ContainerID: c.ID, TeamID: c.TeamID}, proxyTicketTTL); err != nil {
+			return "", err
+		}
+		u.RawQuery = url.Values{ProxyTicketParam: {ticket}}.Encode()
+	}
+	return u.String(), nil
+}
+
+// DirectPorts 返回选手直连的端口映射，启用反向代理时不含通过子域名访问的 http 端口
+func (s *ContainerProxyService) DirectPorts(c *models.Container) models.PortMapping {
+	if !s.Enabled() {
+		return c.HostMapping
+	}
+	ports := make(models.PortMapping, len(c.HostMapping))
+	for key, hostPort := range c.HostMapping {
+		port, _, _ := strings.Cut(key, "/")
+		if !isProxiedPort(c.DockerPorts[port]) {
+			ports[key] = hostPort
+		}
+	}
+	return ports
+}
+
+// GetContainerURL 为团队在该题目上运行中的容器重新生成访问地址
+func (s *ContainerProxyService) GetContainerURL(teamID, challengeID int64) (string, error) {
+	if !s.Enabled() {
+		return "", errors.New("未启用容器反向代理")
+	}
+	var c models.Container
+	if err := config.DB.Where("team_id = ? AND challenge_id = ? AND state = 'running' AND deleted_at IS NULL", teamID, challengeID).
+		First(&c).Error; err != nil {
+		return "", errors.New("未找到运行中的容器")
+	}
+	return s.ContainerURL(&c)
+}
+
+// ResolveHost 解析请求的 Host，ok 为 false 表示不是容器子域名
+// 容器不存在、已停止或已到期时 target 为 nil
+func (s *ContainerProxyService) ResolveHost(host string) (target *ProxyTarget, ok bool, err error) {
+	if !s.Enabled() {
+		return nil, false, nil
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	token, found := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(config.AppConfig.Container.ProxyDomain))
+	if !found || token == "" || strings.Contains(token, ".") {
+		return nil, false, nil
+	}
+
+	ctx := context.Background()
+	key := "proxy_target:" + token
+	if data, hit, err := config.AppCache.Get(ctx, key); err == nil && hit {
+		if len(data) == 0 {
+			return nil, true, nil
+		}
+		var cached ProxyTarget
+		if err := json.Unmarshal(data, &cached); err == nil && time.Now().Before(cached.EndTime) {
+			return &cached, true, nil
+		}
+	}
+
+	var c models.Container
+	err = config.DB.Where("proxy_token = ? AND state = 'running' AND deleted_at IS NULL AND end_time > ?", token, time.Now()).
+		First(&c).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, true, err
+	}
+
+	var data []byte
+	if err == nil {
+		if port := proxyHostPort(&c); port != "" {
+			target = &ProxyTarget{
+				ContainerID: c.ID,
+				TeamID:      c.TeamID,
+				Addr:        net.JoinHostPort(proxyUpstreamHost(), port),
+				EndTime:     c.EndTime,
+			}
+			if data, err = json.Marshal(target); err != nil {
+				return nil, true, err
+			}
+		}
+	}
+	// 未命中的令牌同样缓存（空值），避免随机子域名扫描反复查库
+	_ = config.AppCache.Set(ctx, key, data, proxyTargetTTL)
+	return target, true, nil
+}
+
+// RedeemTicket 兑换一次性票据，返回会话令牌
+func (s *ContainerProxyService) RedeemTicket(ticket string, target *ProxyTarget) (string, error) {
+	ctx := context.Background()
+	key := "proxy_ticket:" + ticket
+	data, ok, err := config.AppCache.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.New("访问票据无效或已过期")
+	}
+	_ = config.AppCache.Delete(ctx, key)
+
+	var grant proxyGrant
+	if err := json.Unmarshal(data, &grant); err != nil || grant.ContainerID != target.ContainerID {
+		return "", errors.New("访问票据无效或已过期")
+	}
+
+	session, err := randomProxyToken()
+	if err != nil {
+		return "", err
+	}
+	grant.ExpiresAt = target.EndTime
+	if err := s.setGrant("proxy_session:"+session, grant, time.Until(target.EndTime)); err != nil {
+		return "", err
+	}
+	return session, nil
+}
+
+// CheckSession 检查会话是否属于目标容器的所属团队
+// 容器续期后 target.EndTime 晚于会话到期时间，此时将会话延长到新的到期时间
+func (s *ContainerProxyService) CheckSession(session string, target *ProxyTarget) bool {
+	key := "proxy_session:" + session
+	data, ok, err := config.AppCache.Get(context.Background(), key)
+	if err != nil || !ok {
+		return false
+	}
+	var grant proxyGrant
+	if err := json.Unmarshal(data, &grant); err != nil {
+		return false
+	}
+	if grant.ContainerID != target.ContainerID || grant.TeamID != target.TeamID {
+		return false
+	}
+	if target.EndTime.After(grant.ExpiresAt) {
+		grant.ExpiresAt = target.EndTime
+		_ = s.setGrant(key, grant, time.Until(target.EndTime))
+	}
+	return true
+}
+
+// InvalidateTarget 容器停止或续期后清除子域名解析缓存，续期后会话随新的到期时间延长
+func (s *ContainerProxyService) InvalidateTarget(token string) {
+	if token != "" {
+		_ = config.AppCache.Delete(context.Background(), "proxy_target:"+token)
+	}
+}
+
+// setGrant 写入票据或会话
+func (s *ContainerProxyService) setGrant(key string, grant proxyGrant, ttl time.Duration) error {
+	data, err := json.Marshal(grant)
+	if err != nil {
+		return err
+	}
+	return config.AppCache.Set(context.Background(), key, data, ttl)
+}
+
+// proxyHostPort 获取容器端口号最小的 http 端口映射到的宿主机端口，未映射时返回空字符串
+func proxyHostPort(c *models.Container) string {
+	return lowestHostPort(c, isProxiedPort)
+}
+
+// proxyUpstreamHost 反向代理连接容器使用的宿主机地址，开启访问鉴权时 http 端口只绑定本机回环地址
+func proxyUpstreamHost() string {
+	bindIP := config.AppConfig.Container.BindIP
+	if bindIP == "" || bindIP == "0.0.0.0" || config.AppConfig.Container.ProxyRequireAuth {
+		return "127.0.0.1"
+	}
+	return bindIP
+}
+
+// isProxiedPort 端口协议声明为 http 时视为 Web 服务，启用反向代理后通过子域名访问
+func isProxiedPort(proto string) bool {
+	return proto == "http"
+}
+
+// dockerPortOptions 转换为 Docker 端口协议（http/https 按 tcp 映射），并返回需单独指定绑定地址的端口
+// 启用反向代理访问鉴权时 http 端口只绑定本机回环地址，选手无法绕过鉴权直连宿主机端口
+func dockerPortOptions(ports models.DockerPorts) (map[string]string, map[string]string) {
+	cfg := config.AppConfig.Container
+	protocols := make(map[string]string, len(ports))
+	hostIPs := make(map[string]string)
+	for port, proto := range ports {
+		switch proto {
+		case "http", "https":
+			protocols[port] = "tcp"
+		default:
+			protocols[port] = proto
+		}
+		if isProxiedPort(proto) && cfg.ProxyDomain != "" && cfg.ProxyRequireAuth {
+			hostIPs[port] = "127.0.0.1"
+		}
+	}
+	return protocols, hostIPs
+}
+
+// randomProxyToken 生成随机令牌（32 位十六进制，可用作子域名）
+func randomProxyToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"isctf/config"
+	"testing"
+	"time"
+)
+
+// TestProxySessionFollowsRenewedContainer 容器续期后，已兑换的会话随新的到期时间延长
+func TestProxySessionFollowsRenewedContainer(t *testing.T) {
+	oldCache := config.AppCache
+	t.Cleanup(func() { config.AppCache = oldCache })
+	config.AppCache = config.NewMemoryCache()
+
+	svc := NewContainerProxyService()
+	target := &ProxyTarget{ContainerID: 1, TeamID: 1, Addr: "127.0.0.1:30002", EndTime: time.Now().Add(time.Second)}
+	if err := svc.setGrant("proxy_ticket:ticket", proxyGrant{ContainerID: 1, TeamID: 1}, proxyTicketTTL); err != nil {
+		t.Fatal(err)
+	}
+	session, err := svc.RedeemTicket("ticket", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 续期：子域名解析得到新的到期时间
+	renewed := *target
+	renewed.EndTime = time.Now().Add(time.Hour)
+	if !svc.CheckSession(session, &renewed) {
+		t.Fatal("续期前会话应有效")
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if !svc.CheckSession(session, &renewed) {
+		t.Fatal("续期后会话不应在原到期时间失效")
+	}
+
+	other := renewed
+	other.TeamID = 2
+	if svc.CheckSession(session, &other) {
+		t.Fatal("会话不应对其他团队的容器有效")
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrContainerTeamLimit 团队运行中（含启动中、排队中）的容器数量已达上限
+	ErrContainerTeamLimit = errors.New("团队运行中的容器数量已达上限，请先停止其他容器")
+	// ErrContainerCapacityFull 平台容器容量已满
+	ErrContainerCapacityFull = errors.New("容器资源已满，请稍后再试或加入排队")
+)
+
+const (
+	// containerQueueInterval 排队调度间隔
+	containerQueueInterval = 5 * time.Second
+	// containerQueueIdleTimeout 排队记录超过该时间未轮询则自动取消，避免为已离开的用户启动容器
+	containerQueueIdleTimeout = 2 * time.Minute
+	// containerQueueStartTimeout 出队后启动超时时间
+	containerQueueStartTimeout = 5 * time.Minute
+)
+
+// StartContainerQueue 启动容器排队调度任务（后台协程）
+func StartContainerQueue() {
+	chalService := NewChallengeService()
+	go func() {
+		ticker := time.NewTicker(containerQueueInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			count, err := chalService.DispatchContainerQueue()
+			if err != nil {
+				fmt.Printf("容器排队调度失败: %v\n", err)
+			}
+			if count > 0 {
+				fmt.Printf("已为排队团队启动 %d 个容器\n", count)
+			}
+		}
+	}()
+}
+
+// reserveContainerSlot 检查团队配额并占用一个全局容量名额
+// 检查与占用在团队行锁内完成，同一团队并发的启动请求依次执行，启动中的容器同样计入配额
+// 全局容量已满或有人排队时（保证先进先出），queue 为 true 则在同一事务内加入排队并返回排队记录，否则返回 ErrContainerCapacityFull
+func (s *ChallengeService) reserveContainerSlot(userID, teamID, challengeID int64, queue bool) (*models.ContainerSlot, *models.ContainerQueue, error) {
+	var slot *models.ContainerSlot
+	var entry *models.ContainerQueue
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var team models.Team
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&team, teamID).Error; err != nil {
+			return err
+		}
+
+		var reserved, queued int64
+		if err := tx.Model(&models.ContainerSlot{}).Where("team_id = ?", teamID).Count(&reserved).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ContainerQueue{}).
+			Where("team_id = ? AND state IN ?", teamID, []string{models.QueueWaiting, models.QueueStarting}).
+			Count(&queued).Error; err != nil {
+			return err
+		}
+		if int(reserved+queued) >= config.AppConfig.Container.TeamLimit {
+			return ErrContainerTeamLimit
+		}
+
+		var waiting int64
+		if err := tx.Model(&models.ContainerQueue{}).Where("state = ?", models.QueueWaiting).Count(&waiting).Error; err != nil {
+			return err
+		}
+		if waiting == 0 {
+			var err error
+			slot, err = NewContainerSlotService().Allocate(tx, teamID)
+			if !errors.Is(err, ErrContainerCapacityFull) {
+				return err
+			}
+		}
+		if !queue {
+			return ErrContainerCapacityFull
+		}
+
+		entry = &models.ContainerQueue{
+			ChallengeID: challengeID,
+			TeamID:      teamID,
+			UserID:      userID,
+			State:       models.QueueWaiting,
+			PolledAt:    time.Now(),
+		}
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return slot, entry, nil
+}
+
+// launchReserved 使用已占用的名额启动容器，成功后名额关联到容器，失败时释放名额
+// 名额在启动期间被回收时销毁容器并返回错误
+func (s *ChallengeService) launchReserved(userID, teamID int64, chal *models.Challenge, slot *models.ContainerSlot) (*models.Container, error) {
+	slots := NewContainerSlotService()
+	c, err := s.launchContainer(userID, teamID, chal)
+	if err != nil {
+		_ = slots.ReleaseSlot(slot.ID)
+		return nil, err
+	}
+	if err := slots.Attach(slot.ID, c.ID); err != nil {
+		// 名额已被回收时保留容器会超出容量上限
+		if stopErr := s.stopContainerInternal(c); stopErr != nil {
+			fmt.Printf("销毁容器 %d 失败: %v\n", c.ID, stopErr)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// activeQueueEntry 查询团队在该题目上排队中或启动中的记录，并刷新轮询时间
+func (s *ChallengeService) activeQueueEntry(teamID, challengeID int64) (*dto.ContainerQueueResponse, error) {
+	var entry models.ContainerQueue
+	err := config.DB.Where("team_id = ? AND challenge_id = ? AND state IN ?",
+		teamID, challengeID, []string{models.QueueWaiting, models.QueueStarting}).
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := config.DB.Model(&entry).Update("polled_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+	return s.queueResponse(&entry)
+}
+
+// GetContainerQueue 轮询团队在该题目上最近一次排队的状态
+func (s *ChallengeService) GetContainerQueue(teamID, challengeID int64) (*dto.ContainerQueueResponse, error) {
+	var entry models.ContainerQueue
+	if err := config.DB.Where("team_id = ? AND challenge_id = ?", teamID, challengeID).
+		Order("id DESC").
+		First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("未找到排队记录")
+		}
+		return nil, err
+	}
+	if entry.State == models.QueueWaiting {
+		if err := config.DB.Model(&entry).Update("polled_at", time.Now()).Error; err != nil {
+			return nil, err
+		}
+	}
+	return s.queueResponse(&entry)
+}
+
+// CancelContainerQueue 取消团队在该题目上的排队
+func (s *ChallengeService) CancelContainerQueue(teamID, challengeID int64) error {
+	result := config.DB.Model(&models.ContainerQueue{}).
+		Where("team_id = ? AND challenge_id = ? AND state = ?", teamID, challengeID, models.QueueWaiting).
+		Update("state", models.QueueCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("未找到排队记录")
+	}
+	return nil
+}
+
+// queueResponse 构造排队状态响应，排队中的记录计算当前位置
+func (s *ChallengeService) queueResponse(entry *models.ContainerQueue) (*dto.ContainerQueueResponse, error) {
+	resp := &dto.ContainerQueueResponse{
+		ID:          entry.ID,
+		ChallengeID: entry.ChallengeID,
+		State:       entry.State,
+		ContainerID: entry.ContainerID,
+		Error:       entry.Error,
+		CreatedAt:   entry.CreatedAt,
+	}
+	if entry.State == models.QueueWaiting {
+		if err := config.DB.Model(&models.ContainerQueue{}).
+			Where("state = ? AND id < ?", models.QueueWaiting, entry.ID).
+			Count(&resp.Position).Error; err != nil {
+			return nil, err
+		}
+		resp.Position++
+	}
+	return resp, nil
+}
+
+// DispatchContainerQueue 按先进先出顺序为排队团队启动容器，直到全局容量用尽
+func (s *ChallengeService) DispatchContainerQueue() (int, error) {
+	// 长时间未轮询的排队记录视为用户已离开
+	if err := config.DB.Model(&models.ContainerQueue{}).
+		Where("state = ? AND polled_at < ?", models.QueueWaiting, time.Now().Add(-containerQueueIdleTimeout)).
+		Updates(map[string]interface{}{"state": models.QueueCancelled, "error": "长时间未轮询，已取消排队"}).Error; err != nil {
+		return 0, err
+	}
+	// 启动过程中实例退出遗留的记录
+	if err := config.DB.Model(&models.ContainerQueue{}).
+		Where("state = ? AND updated_at < ?", models.QueueStarting, time.Now().Add(-containerQueueStartTimeout)).
+		Updates(map[string]interface{}{"state": models.QueueFailed, "error": "容器启动超时"}).Error; err != nil {
+		return 0, err
+	}
+
+	started := 0
+	for {
+		var entry models.ContainerQueue
+		if err := config.DB.Where("state = ?", models.QueueWaiting).Order("id ASC").First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return started, nil
+			}
+			return started, err
+		}
+		// 排队记录已计入团队配额，这里只占用全局名额
+		slot, err := NewContainerSlotService().Allocate(config.DB, entry.TeamID)
+		if err != nil {
+			if errors.Is(err, ErrContainerCapacityFull) {
+				return started, nil
+			}
+			return started, err
+		}
+
+		// 抢占该记录，多实例部署时只有一个实例能出队成功
+		result := config.DB.Model(&models.ContainerQueue{}).
+			Where("id = ? AND state = ?", entry.ID, models.QueueWaiting).
+			Update("state", models.QueueStarting)
+		if result.Error != nil || result.RowsAffected == 0 {
+			_ = NewContainerSlotService().ReleaseSlot(slot.ID)
+			if result.Error != nil {
+				return started, result.Error
+			}
+			continue
+		}
+
+		c, err := s.startQueuedContainer(&entry, slot)
+		if err != nil {
+			config.DB.Model(&entry).Updates(map[string]interface{}{"state": models.QueueFailed, "error": truncateString(err.Error(), 255)})
+			continue
+		}
+		config.DB.Model(&entry).Updates(map[string]interface{}{"state": models.QueueStarted, "container_id": c.ID})
+		started++
+	}
+}
+
+// startQueuedContainer 使用已占用的名额为出队的记录启动容器，启动前重新检查题目访问权限，未启动时释放名额
+func (s *ChallengeService) startQueuedContainer(entry *models.ContainerQueue, slot *models.ContainerSlot) (*models.Container, error) {
+	var chal models.Challenge
+	err := config.DB.First(&chal, entry.ChallengeID).Error
+	switch {
+	case err != nil:
+		err = errors.New("题目不存在")
+	case chal.Mode != "dynamic":
+		err = errors.New("非动态题目无需启动容器")
+	default:
+		err = s.CheckChallengeAccess(entry.TeamID, &chal)
+	}
+	if err != nil {
+		_ = NewContainerSlotService().ReleaseSlot(slot.ID)
+		return nil, err
+	}
+	if existing, err := s.runningContainer(entry.TeamID, entry.ChallengeID); err != nil || existing != nil {
+		_ = NewContainerSlotService().ReleaseSlot(slot.ID)
+		return existing, err
+	}
+	return s.launchReserved(entry.UserID, entry.TeamID, &chal, slot)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+	"isctf/config"
+	"isctf/models"
+	"isctf/utils"
+	"time"
+)
+
+const (
+	// containerReconcileInterval 容器状态同步间隔
+	containerReconcileInterval = time.Minute
+	// orphanGracePeriod 孤儿容器宽限期：容器先于数据库记录创建，刚创建的容器不视为孤儿
+	orphanGracePeriod = 2 * time.Minute
+)
+
+// ContainerReconcileResult 一次容器状态同步的结果
+type ContainerReconcileResult struct {
+	Expired int // 到期销毁的容器数
+	Stopped int // Docker 中已退出或不存在而停止的容器数
+	Removed int // 清理的孤儿容器数
+}
+
+// StartContainerReconciler 启动容器状态同步任务（后台协程，每分钟一次）
+func StartContainerReconciler() {
+	chalService := NewChallengeService()
+	go func() {
+		ticker := time.NewTicker(containerReconcileInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			result, err := chalService.ReconcileContainers()
+			if err != nil {
+				fmt.Printf("容器状态同步失败: %v\n", err)
+				continue
+			}
+			if result.Expired > 0 || result.Stopped > 0 || result.Removed > 0 {
+				fmt.Printf("容器状态同步: 到期销毁 %d 个，标记停止 %d 个，清理孤儿 %d 个\n",
+					result.Expired, result.Stopped, result.Removed)
+			}
+		}
+	}()
+}
+
+// ReconcileContainers 将数据库中的容器状态与 Docker 实际状态对齐
+//  1. 已到期的运行中容器：销毁
+//  2. Docker 中已退出或不存在的运行中或预热容器：停止并删除残留的 Docker 容器
+//  3. 已停止但 Docker 资源未删除完的容器：重试删除，完成后标记为 destroyed 并释放端口
+//  4. 带平台标签但没有数据库记录的 Docker 容器（孤儿）：删除，随后清理没有容器的私有网络
+//  5. 回收不再被容器占用的端口租约与容量名额
+func (s *ChallengeService) ReconcileContainers() (*ContainerReconcileResult, error) {
+	result := &ContainerReconcileResult{}
+
+	dockerContainers, err := utils.ListPlatformContainers()
+	if err != nil {
+		return nil, fmt.Errorf("获取 Docker 容器列表失败: %v", err)
+	}
+	dockerStates := make(map[string]string, len(dockerContainers))
+	for _, dc := range dockerContainers {
+		dockerStates[dc.ID] = dc.State
+	}
+
+	var running []models.Container
+	if err := config.DB.Where("state IN ('running', 'warm', 'stopped') AND deleted_at IS NULL").Find(&running).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	portPool := NewPortPoolService()
+	// handled 记录已由数据库记录处理过的 Docker 容器，其余带标签的容器即为孤儿
+	handled := make(map[string]bool, len(running))
+	for i := range running {
+		c := &running[i]
+		for _, id := range dockerIDs(c) {
+			handled[id] = true
+		}
+
+		if c.State == "stopped" {
+			if err := s.destroyStoppedContainer(c); err != nil {
+				fmt.Printf("删除已停止容器 %d 失败: %v\n", c.ID, err)
+			}
+			continue
+		}
+
+		if c.State == "running" && now.After(c.EndTime) {
+			if err := s.stopContainerInternal(c); err != nil {
+				fmt.Printf("销毁到期容器 %d 失败: %v\n", c.ID, err)
+				continue
+			}
+			result.Expired++
+			continue
+		}
+
+		// 多容器题目任一服务退出即视为整组失效
+		state, err := groupDockerState(c, dockerStates)
+		if err != nil {
+			fmt.Printf("查询容器 %d 状态失败: %v\n", c.ID, err)
+			continue
+		}
+		if state == "running" {
+			continue
+		}
+
+		// 容器已退出、崩溃或被手动删除，预热容器由预热池补充
+		if c.State == "warm" {
+			if _, err := s.destroyWarmContainer(c); err != nil {
+				fmt.Printf("销毁预热容器 %d 失败: %v\n", c.ID, err)
+				continue
+			}
+			result.Stopped++
+			continue
+		}
+		if err := config.DB.Model(c).Update("state", "stopped").Error; err != nil {
+			fmt.Printf("更新容器 %d 状态失败: %v\n", c.ID, err)
+			continue
+		}
+		NewContainerProxyService().InvalidateTarget(c.ProxyToken)
+		result.Stopped++
+		if err := s.destroyStoppedContainer(c); err != nil {
+			fmt.Printf("删除已停止容器 %d 失败: %v\n", c.ID, err)
+		}
+	}
+
+	// 清理孤儿容器
+	for _, dc := range dockerContainers {
+		if handled[dc.ID] || now.Sub(dc.Created) < orphanGracePeriod {
+			continue
+		}
+		if err := utils.RemoveContainer(dc.ID); err != nil && !utils.IsContainerNotFound(err) {
+			fmt.Printf("清理孤儿容器 %s 失败: %v\n", dc.ID, err)
+			continue
+		}
+		result.Removed++
+	}
+
+	// 清理多容器题目遗留的空私有网络
+	if _, err := utils.PrunePlatformNetworks(orphanGracePeriod); err != nil {
+		fmt.Printf("清理私有网络失败: %v\n", err)
+	}
+
+	// 回收失效的端口租约与容量名额
+	if _, err := portPool.ReleaseStale(); err != nil {
+		fmt.Printf("回收端口租约失败: %v\n", err)
+	}
+	if _, err := NewContainerSlotService().ReleaseStale(); err != nil {
+		fmt.Printf("回收容量名额失败: %v\n", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"isctf/config"
+	"isctf/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// containerSlotGracePeriod 未关联容器的名额保留时间，超时视为启动中断遗留
+	containerSlotGracePeriod = 5 * time.Minute
+	// containerSlotMaxConflicts 并发占用名额冲突的最大重试次数
+	containerSlotMaxConflicts = 10
+)
+
+// ErrContainerSlotExpired 启动耗时超过保留时间，未关联的容量名额已被回收
+var ErrContainerSlotExpired = errors.New("容器启动超时，容量名额已失效，请重试")
+
+// ContainerSlotService 全局容器容量名额服务，名额记录在数据库中，多实例部署时共享同一容量
+type ContainerSlotService struct{}
+
+// NewContainerSlotService 创建容器容量名额服务实例
+func NewContainerSlotService() *ContainerSlotService {
+	return &ContainerSlotService{}
+}
+
+// Allocate 在 db（可为事务）中占用一个空闲名额，容量已满时返回 ErrContainerCapacityFull
+func (s *ContainerSlotService) Allocate(db *gorm.DB, teamID int64) (*models.ContainerSlot, error) {
+	limit := config.AppConfig.Container.GlobalLimit
+	var used []int
+	if err := db.Model(&models.ContainerSlot{}).Pluck("slot", &used).Error; err != nil {
+		return nil, err
+	}
+	// 调小容量上限后，超出部分的名额在容器销毁前仍然有效
+	if len(used) >= limit {
+		return nil, ErrContainerCapacityFull
+	}
+	taken := make(map[int]bool, len(used))
+	for _, n := range used {
+		taken[n] = true
+	}
+
+	conflicts := 0
+	for n := 1; n <= limit; n++ {
+		if taken[n] {
+			continue
+		}
+		slot := &models.ContainerSlot{Slot: n, TeamID: teamID}
+		if err := db.Create(slot).Error; err != nil {
+			// 唯一索引冲突：名额刚被其他实例占用
+			conflicts++
+			if conflicts > containerSlotMaxConflicts {
+				return nil, err
+			}
+			continue
+		}
+		return slot, nil
+	}
+	return nil, ErrContainerCapacityFull
+}
+
+// Attach 将名额关联到容器记录，容器已持有名额（领取的预热容器）时释放该名额
+// 名额超过保留时间被回收时返回 ErrContainerSlotExpired，调用方需销毁该容器，避免超出容量上限
+func (s *ContainerSlotService) Attach(slotID, containerID int64) error {
+	var count int64
+	if err := config.DB.Model(&models.ContainerSlot{}).Where("container_id = ?", containerID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return s.ReleaseSlot(slotID)
+	}
+	result := config.DB.Model(&models.ContainerSlot{}).
+		Where("id = ? AND container_id = 0", slotID).
+		Update("container_id", containerID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrContainerSlotExpired
+	}
+	return nil
+}
+
+// Release 释放容器占用的名额
+func (s *ContainerSlotService) Release(containerID int64) error {
+	return config.DB.Where("container_id = ?", containerID).Delete(&models.ContainerSlot{}).Error
+}
+
+// ReleaseSlot 释放尚未关联容器的名额（用于启动失败时回滚）
+func (s *ContainerSlotService) ReleaseSlot(slotID int64) error {
+	return config.DB.Where("id = ? AND container_id = 0", slotID).Delete(&models.ContainerSlot{}).Error
+}
+
+// ReleaseStale 释放失效名额：启动中断遗留的未关联名额，以及容器已销毁的名额
+func (s *ContainerSlotService) ReleaseStale() (int64, error) {
+	result := config.DB.
+		Where("(container_id = 0 AND created_at < ?) OR (container_id <> 0 AND container_id NOT IN (?))",
+			time.Now().Add(-containerSlotGracePeriod),
+			config.DB.Model(&models.Container{}).Select("id").Where("state IN ('running', 'warm', 'stopped') AND deleted_at IS NULL")).
+		Delete(&models.ContainerSlot{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"isctf/config"
+	"isctf/models"
+	"testing"
+	"time"
+)
+
+// TestAttachAfterReservationExpired 启动耗时超过保留时间、名额与租约被回收后，关联必须失败
+func TestAttachAfterReservationExpired(t *testing.T) {
+	oldConfig := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = oldConfig })
+	config.AppConfig = &config.Config{
+		Server:    config.ServerConfig{Mode: "test"},
+		Container: config.ContainerConfig{GlobalLimit: 1},
+	}
+	db := setupTestDB(t, &models.Container{}, &models.ContainerSlot{}, &models.PortLease{})
+
+	slots := NewContainerSlotService()
+	slot, err := slots.Allocate(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lease := &models.PortLease{Port: 30001, TeamID: 1, ChallengeID: 1}
+	if err := db.Create(lease).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟镜像拉取耗时超过保留时间
+	expired := time.Now().Add(-containerSlotGracePeriod - time.Minute)
+	db.Model(&models.ContainerSlot{}).Where("id = ?", slot.ID).Update("created_at", expired)
+	db.Model(&models.PortLease{}).Where("port = ?", lease.Port).Update("created_at", expired)
+	if _, err := slots.ReleaseStale(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPortPoolService().ReleaseStale(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 回收后名额可被其他团队占用
+	if _, err := slots.Allocate(db, 2); err != nil {
+		t.Fatalf("回收后应能重新占用名额: %v", err)
+	}
+	if err := slots.Attach(slot.ID, 1); !errors.Is(err, ErrContainerSlotExpired) {
+		t.Fatalf("slot Attach err = %v, want ErrContainerSlotExpired", err)
+	}
+	if err := NewPortPoolService().Attach([]int{lease.Port}, 1); !errors.Is(err, ErrPortLeaseExpired) {
+		t.Fatalf("port Attach err = %v, want ErrPortLeaseExpired", err)
+	}
+}
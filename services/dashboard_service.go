@@ -0,0 +1,533 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"log"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	dashboardOverviewTTL = 5 * time.Second
+	dashboardTrendTTL    = 30 * time.Second
+	dashboardMatrixTTL   = 10 * time.Second
+
+	dashboardTopTeams     = 10
+	dashboardRecentSolves = 5
+
+	// 趋势图采样：最小间隔 10 分钟，最多 48 个采样点
+	trendMinInterval = 10 * time.Minute
+	trendMaxPoints   = 48
+)
+
+// dashboardGroup 合并同一缓存键的并发重算，避免缓存过期瞬间击穿数据库
+var dashboardGroup singleflight.Group
+
+// DashboardService 比赛大屏服务
+type DashboardService struct{}
+
+// NewDashboardService 创建比赛大屏服务实例
+func NewDashboardService() *DashboardService {
+	return &DashboardService{}
+}
+
+// GetOverview 获取大屏聚合数据（缓存 5 秒）
+func (s *DashboardService) GetOverview() (*dto.DashboardOverviewResponse, error) {
+	var resp dto.DashboardOverviewResponse
+	err := s.cached("dashboard:overview", dashboardOverviewTTL, &resp, func() (interface{}, error) {
+		return s.buildOverview()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetTrend 获取前 top 名队伍的得分趋势（缓存 30 秒）
+func (s *DashboardService) GetTrend(req *dto.DashboardTrendRequest) (*dto.DashboardTrendResponse, error) {
+	if req.Top == 0 {
+		req.Top = dashboardTopTeams
+	}
+
+	var resp dto.DashboardTrendResponse
+	key := fmt.Sprintf("dashboard:trend:%d", req.Top)
+	err := s.cached(key, dashboardTrendTTL, &resp, func() (interface{}, error) {
+		return s.buildTrend(req.Top)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetSolveMatrix 获取队伍 × 题目的解题矩阵（缓存 10 秒）
+func (s *DashboardService) GetSolveMatrix(req *dto.SolveMatrixRequest) (*dto.SolveMatrixResponse, error) {
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+
+	var resp dto.SolveMatrixResponse
+	key := fmt.Sprintf("dashboard:matrix:%s:%d:%d", req.Track, req.Page, req.Limit)
+	err := s.cached(key, dashboardMatrixTTL, &resp, func() (interface{}, error) {
+		return s.buildSolveMatrix(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// cached 读取缓存，未命中时调用 load 计算并写回
+// 缓存读写失败只记录日志并回退到直接计算，不影响接口可用性
+func (s *DashboardService) cached(key string, ttl time.Duration, dest interface{}, load func() (interface{}, error)) error {
+	ctx := context.Background()
+	if data, ok, err := config.AppCache.Get(ctx, key); err != nil {
+		log.Printf("读取大屏缓存失败 %s: %v", key, err)
+	} else if ok {
+		return json.Unmarshal(data, dest)
+	}
+
+	v, err, _ := dashboardGroup.Do(key, func() (interface{}, error) {
+		result, err := load()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		if err := config.AppCache.Set(ctx, key, data, ttl); err != nil {
+			log.Printf("写入大屏缓存失败 %s: %v", key, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(v.([]byte), dest)
+}
+
+// competitionStatus 根据比赛配置计算比赛状态与倒计时（秒）
+func competitionStatus(now time.Time) (string, int64) {
+	comp := config.AppConfig.Competition
+	if comp.StartTime != nil && now.Before(*comp.StartTime) {
+		return "not_started", int64(comp.StartTime.Sub(now).Seconds())
+	}
+	if comp.EndTime != nil && !now.Before(*comp.EndTime) {
+		return "ended", 0
+	}
+
+	var countdown int64
+	if comp.EndTime != nil {
+		countdown = int64(comp.EndTime.Sub(now).Seconds())
+	}
+	if comp.Paused {
+		return "paused", countdown
+	}
+	return "running", countdown
+}
+
+// buildOverview 计算大屏聚合数据
+func (s *DashboardService) buildOverview() (*dto.DashboardOverviewResponse, error) {
+	now := time.Now()
+	status, countdown := competitionStatus(now)
+	resp := &dto.DashboardOverviewResponse{
+		CompetitionStatus: status,
+		Countdown:         countdown,
+		StartTime:         config.AppConfig.Competition.StartTime,
+		EndTime:           config.AppConfig.Competition.EndTime,
+		TopTeams:          make([]dto.DashboardTopTeam, 0),
+		RecentSolves:      make([]dto.DashboardSolve, 0),
+		CategoryProgress:  make([]dto.DashboardCategoryProgress, 0),
+		GeneratedAt:       now,
+	}
+
+	// 统计数据
+	if err := config.DB.Model(&models.Team{}).
+		Where("status = 'active' AND deleted_at IS NULL").
+		Count(&resp.Stats.TotalTeams).Error; err != nil {
+		return nil, err
+	}
+	if err := config.DB.Model(&models.SubmissionLog{}).
+		Where("deleted_at IS NULL").
+		Distinct("team_id").
+		Count(&resp.Stats.ActiveTeams).Error; err != nil {
+		return nil, err
+	}
+	if err := config.DB.Model(&models.Solve{}).
+		Where("deleted_at IS NULL").
+		Count(&resp.Stats.TotalSolves).Error; err != nil {
+		return nil, err
+	}
+	if err := config.DB.Model(&models.SubmissionLog{}).
+		Where("deleted_at IS NULL").
+		Count(&resp.Stats.TotalFlagsSubmitted).Error; err != nil {
+		return nil, err
+	}
+
+	// 前 10 名队伍（与总榜计分一致）
+	teams, err := s.topTeams(dashboardTopTeams)
+	if err != nil {
+		return nil, err
+	}
+	for i, team := range teams {
+		resp.TopTeams = append(resp.TopTeams, dto.DashboardTopTeam{
+			Rank:       i + 1,
+			TeamID:     team.ID,
+			TeamName:   team.TeamName,
+			Score:      team.RankScore,
+			SchoolName: team.SchoolName,
+		})
+	}
+
+	// 最近解题记录，仅展示当前可见题目
+	if err := config.DB.Table("dalictf_solve AS s").
+		Select("t.team_name, c.challenge_name, s.solving_time AS time, s.is_first_blood").
+		Joins("JOIN dalictf_team AS t ON t.id = s.team_id").
+		Joins("JOIN dalictf_challenge AS c ON c.id = s.challenge_id").
+		Where("s.deleted_at IS NULL AND t.status = 'active' AND c.state = 'visible' AND c.deleted_at IS NULL").
+		Order("s.solving_time DESC, s.id DESC").
+		Limit(dashboardRecentSolves).
+		Scan(&resp.RecentSolves).Error; err != nil {
+		return nil, err
+	}
+
+	// 各分类解题进度
+	var categories []models.ChallengeCategory
+	if err := config.DB.Where("status = 'active' AND deleted_at IS NULL").
+		Order("sort_order ASC, id ASC").
+		Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	type directionCount struct {
+		Direction string
+		Count     int64
+	}
+	var totals []directionCount
+	if err := config.DB.Model(&models.Challenge{}).
+		Where("state = 'visible' AND deleted_at IS NULL AND (release_at IS NULL OR release_at <= ?)", now).
+		Select("direction, COUNT(*) AS count").
+		Group("direction").
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	var solved []directionCount
+	if err := config.DB.Table("dalictf_solve AS s").
+		Select("c.direction, COUNT(*) AS count").
+		Joins("JOIN dalictf_challenge AS c ON c.id = s.challenge_id").
+		Where("s.deleted_at IS NULL AND c.state = 'visible' AND c.deleted_at IS NULL").
+		Group("c.direction").
+		Scan(&solved).Error; err != nil {
+		return nil, err
+	}
+
+	totalMap := make(map[string]int64, len(totals))
+	for _, t := range totals {
+		totalMap[t.Direction] = t.Count
+	}
+	solvedMap := make(map[string]int64, len(solved))
+	for _, sc := range solved {
+		solvedMap[sc.Direction] = sc.Count
+	}
+	for _, category := range categories {
+		resp.CategoryProgress = append(resp.CategoryProgress, dto.DashboardCategoryProgress{
+			Direction:   category.Direction,
+			Name:        category.NameZh,
+			SolvedCount: solvedMap[category.Direction],
+			TotalCount:  totalMap[category.Direction],
+		})
+	}
+
+	return resp, nil
+}
+
+// topTeams 按总榜计分取前 limit 名正常状态的队伍
+func (s *DashboardService) topTeams(limit int) ([]rankedTeam, error) {
+	query := config.DB.Model(&models.Team{}).Where("dalictf_team.status = 'active' AND dalictf_team.deleted_at IS NULL")
+	query = NewTeamService().scopeRankScore(query, "")
+
+	var teams []rankedTeam
+	if err := query.Limit(limit).Scan(&teams).Error; err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// scoreEvent 队伍得分变化事件（解题加分或解锁提示扣分）
+type scoreEvent struct {
+	TeamID int64
+	Delta  int
+	Time   time.Time
+}
+
+// buildTrend 计算前 top 名队伍的得分趋势
+// 与总榜计分一致：只累计不限赛道题目的得分，并扣除这些题目的提示解锁花费
+func (s *DashboardService) buildTrend(top int) (*dto.DashboardTrendResponse, error) {
+	now := time.Now()
+	resp := &dto.DashboardTrendResponse{
+		Times:       make([]time.Time, 0),
+		Series:      make([]dto.DashboardTrendSeries, 0),
+		GeneratedAt: now,
+	}
+
+	teams, err := s.topTeams(top)
+	if err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return resp, nil
+	}
+
+	teamIDs := make([]int64, 0, len(teams))
+	for _, team := range teams {
+		teamIDs = append(teamIDs, team.ID)
+	}
+
+	// 一次性取出所有得分事件
+	var events []scoreEvent
+	if err := config.DB.Table("dalictf_solve AS s").
+		Select("s.team_id, s.earned_score AS delta, s.solving_time AS time").
+		Joins("JOIN dalictf_challenge AS c ON c.id = s.challenge_id").
+		Where("s.team_id IN ? AND s.deleted_at IS NULL AND c.tracks = ''", teamIDs).
+		Scan(&events).Error; err != nil {
+		return nil, err
+	}
+	var unlocks []scoreEvent
+	if err := config.DB.Table("dalictf_hint_unlock AS h").
+		Select("h.team_id, -h.cost AS delta, h.created_at AS time").
+		Joins("JOIN dalictf_challenge AS c ON c.id = h.challenge_id").
+		Where("h.team_id IN ? AND c.tracks = ''", teamIDs).
+		Scan(&unlocks).Error; err != nil {
+		return nil, err
+	}
+	events = append(events, unlocks...)
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	// 采样区间：比赛开始（未配置时取第一条事件）至 min(当前时间, 比赛结束)
+	end := now
+	if endTime := config.AppConfig.Competition.EndTime; endTime != nil && endTime.Before(end) {
+		end = *endTime
+	}
+	start := end
+	if startTime := config.AppConfig.Competition.StartTime; startTime != nil {
+		start = *startTime
+	} else if len(events) > 0 {
+		start = events[0].Time
+	}
+	if start.After(end) {
+		start = end
+	}
+	resp.Times = trendSamplePoints(start, end)
+
+	// 按时间点顺序扫描事件，累计各队分数
+	index := make(map[int64]int, len(teams))
+	for i, team := range teams {
+		index[team.ID] = i
+		resp.Series = append(resp.Series, dto.DashboardTrendSeries{
+			TeamID:   team.ID,
+			TeamName: team.TeamName,
+			Scores:   make([]int, 0, len(resp.Times)),
+		})
+	}
+	current := make([]int, len(teams))
+	next := 0
+	for _, point := range resp.Times {
+		for next < len(events) && !events[next].Time.After(point) {
+			current[index[events[next].TeamID]] += events[next].Delta
+			next++
+		}
+		for i := range resp.Series {
+			resp.Series[i].Scores = append(resp.Series[i].Scores, current[i])
+		}
+	}
+
+	return resp, nil
+}
+
+// trendSamplePoints 在 [start, end] 间按固定间隔生成采样时间点，末尾总包含 end
+func trendSamplePoints(start, end time.Time) []time.Time {
+	span := end.Sub(start)
+	interval := trendMinInterval
+	if span > interval*trendMaxPoints {
+		interval = (span/trendMaxPoints + time.Minute - 1).Truncate(time.Minute)
+	}
+
+	points := make([]time.Time, 0, trendMaxPoints+2)
+	for t := start; t.Before(end); t = t.Add(interval) {
+		points = append(points, t)
+	}
+	return append(points, end)
+}
+
+// buildSolveMatrix 计算解题矩阵
+// 列为当前赛道可见的题目，按分类排序顺序分组；行为该赛道排行榜中的一页队伍
+// 全部数据通过批量查询获取，查询次数与队伍、题目数量无关
+func (s *DashboardService) buildSolveMatrix(req *dto.SolveMatrixRequest) (*dto.SolveMatrixResponse, error) {
+	now := time.Now()
+	resp := &dto.SolveMatrixResponse{
+		Track:       req.Track,
+		Page:        req.Page,
+		Limit:       req.Limit,
+		Categories:  make([]dto.SolveMatrixCategory, 0),
+		Teams:       make([]dto.SolveMatrixTeam, 0),
+		GeneratedAt: now,
+	}
+
+	// 题目：已放出、可见且对该赛道开放
+	var challenges []models.Challenge
+	db := config.DB.Select("id, challenge_name, direction, current_score").
+		Where("state = 'visible' AND deleted_at IS NULL AND (release_at IS NULL OR release_at <= ?)", now)
+	db = NewChallengeService().scopeTrack(db, req.Track)
+	if err := db.Order("id ASC").Find(&challenges).Error; err != nil {
+		return nil, err
+	}
+
+	// 每题解出次数，仅统计该赛道正常状态的队伍
+	challengeIDs := make([]int64, 0, len(challenges))
+	for _, chal := range challenges {
+		challengeIDs = append(challengeIDs, chal.ID)
+	}
+	solveCounts := make(map[int64]int64, len(challenges))
+	if len(challengeIDs) > 0 {
+		type challengeCount struct {
+			ChallengeID int64
+			Count       int64
+		}
+		var counts []challengeCount
+		countQuery := config.DB.Table("dalictf_solve AS s").
+			Select("s.challenge_id, COUNT(*) AS count").
+			Joins("JOIN dalictf_team AS t ON t.id = s.team_id").
+			Where("s.deleted_at IS NULL AND t.status = 'active' AND s.challenge_id IN ?", challengeIDs)
+		if req.Track != "" {
+			countQuery = countQuery.Where("t.team_track = ?", req.Track)
+		}
+		if err := countQuery.Group("s.challenge_id").Scan(&counts).Error; err != nil {
+			return nil, err
+		}
+		for _, c := range counts {
+			solveCounts[c.ChallengeID] = c.Count
+		}
+	}
+
+	// 按分类分组，未配置分类的方向排在最后
+	var categories []models.ChallengeCategory
+	if err := config.DB.Where("deleted_at IS NULL").
+		Order("sort_order ASC, id ASC").
+		Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	groups := make(map[string]*dto.SolveMatrixCategory)
+	order := make([]string, 0, len(categories))
+	for _, category := range categories {
+		groups[category.Direction] = &dto.SolveMatrixCategory{
+			Direction:  category.Direction,
+			Name:       category.NameZh,
+			Challenges: make([]dto.SolveMatrixChallenge, 0),
+		}
+		order = append(order, category.Direction)
+	}
+	for _, chal := range challenges {
+		group, ok := groups[chal.Direction]
+		if !ok {
+			group = &dto.SolveMatrixCategory{
+				Direction:  chal.Direction,
+				Name:       chal.Direction,
+				Challenges: make([]dto.SolveMatrixChallenge, 0),
+			}
+			groups[chal.Direction] = group
+			order = append(order, chal.Direction)
+		}
+		group.Challenges = append(group.Challenges, dto.SolveMatrixChallenge{
+			ID:         chal.ID,
+			Name:       chal.ChallengeName,
+			Score:      chal.CurrentScore,
+			SolveCount: solveCounts[chal.ID],
+		})
+		group.ChallengeCount++
+		group.SolveCount += solveCounts[chal.ID]
+	}
+	for _, direction := range order {
+		group := groups[direction]
+		if group.ChallengeCount == 0 {
+			continue
+		}
+		group.AverageSolves = float64(group.SolveCount) / float64(group.ChallengeCount)
+		resp.Categories = append(resp.Categories, *group)
+	}
+
+	// 队伍：与对应排行榜的排序一致
+	teamQuery := config.DB.Model(&models.Team{}).Where("dalictf_team.status = 'active' AND dalictf_team.deleted_at IS NULL")
+	if req.Track != "" {
+		teamQuery = teamQuery.Where("dalictf_team.team_track = ?", req.Track)
+	}
+	if err := teamQuery.Count(&resp.Total).Error; err != nil {
+		return nil, err
+	}
+
+	var teams []rankedTeam
+	offset := (req.Page - 1) * req.Limit
+	if err := NewTeamService().scopeRankScore(teamQuery, req.Track).
+		Offset(offset).Limit(req.Limit).
+		Scan(&teams).Error; err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return resp, nil
+	}
+
+	teamIDs := make([]int64, 0, len(teams))
+	rows := make(map[int64]*dto.SolveMatrixTeam, len(teams))
+	for i, team := range teams {
+		teamIDs = append(teamIDs, team.ID)
+		resp.Teams = append(resp.Teams, dto.SolveMatrixTeam{
+			Rank:     offset + i + 1,
+			TeamID:   team.ID,
+			TeamName: team.TeamName,
+			Score:    team.RankScore,
+			Solves:   make(map[int64]dto.SolveMatrixCell),
+		})
+	}
+	for i := range resp.Teams {
+		rows[resp.Teams[i].TeamID] = &resp.Teams[i]
+	}
+	if len(challengeIDs) == 0 {
+		return resp, nil
+	}
+
+	// 单元格：该页队伍在上述题目上的全部解题记录
+	var solves []models.Solve
+	if err := config.DB.Select("team_id, challenge_id, solving_time, is_first_blood, is_second_blood, is_third_blood").
+		Where("deleted_at IS NULL AND team_id IN ? AND challenge_id IN ?", teamIDs, challengeIDs).
+		Find(&solves).Error; err != nil {
+		return nil, err
+	}
+	for _, solve := range solves {
+		row := rows[solve.TeamID]
+		cell := dto.SolveMatrixCell{SolvingTime: solve.SolvingTime}
+		switch {
+		case solve.IsFirstBlood:
+			cell.Blood = 1
+		case solve.IsSecondBlood:
+			cell.Blood = 2
+		case solve.IsThirdBlood:
+			cell.Blood = 3
+		}
+		row.Solves[solve.ChallengeID] = cell
+		row.SolveCount++
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"errors"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HintService 题目提示服务
+type HintService struct{}
+
+// NewHintService 创建提示服务实例
+func NewHintService() *HintService {
+	return &HintService{}
+}
+
+// CreateHint 创建题目提示（管理员）
+func (s *HintService) CreateHint(op *Operator, challengeID int64, req *dto.HintRequest) (*models.ChallengeHint, error) {
+	var count int64
+	if err := config.DB.Model(&models.Challenge{}).
+		Where("id = ? AND deleted_at IS NULL", challengeID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("题目不存在")
+	}
+
+	hint := &models.ChallengeHint{
+		ChallengeID: challengeID,
+		Content:     req.Content,
+		Cost:        req.Cost,
+		ReleaseAt:   req.ReleaseAt,
+		SortOrder:   req.SortOrder,
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(hint).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditHintCreate, "hint", hint.ID, nil, hint)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hint, nil
+}
+
+// UpdateHint 修改题目提示（管理员）
+// 已解锁队伍的扣分记录不受影响
+func (s *HintService) UpdateHint(op *Operator, challengeID, hintID int64, req *dto.HintRequest) (*models.ChallengeHint, error) {
+	hint, err := s.findHint(challengeID, hintID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *hint
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(hint).Updates(map[string]interface{}{
+			"content":    req.Content,
+			"cost":       req.Cost,
+			"release_at": req.ReleaseAt,
+			"sort_order": req.SortOrder,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.First(hint, hintID).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditHintUpdate, "hint", hintID, &before, hint)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hint, nil
+}
+
+// DeleteHint 删除题目提示（软删除）
+func (s *HintService) DeleteHint(op *Operator, challengeID, hintID int64) error {
+	hint, err := s.findHint(challengeID, hintID)
+	if err != nil {
+		return err
+	}
+	before := *hint
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(hint).Update("deleted_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditHintDelete, "hint", hintID, &before, nil)
+	})
+}
+
+// GetHints 获取题目提示列表（选手侧）
+// 未放出的提示不返回；付费提示仅在本队解锁后返回内容。teamID 为 0 表示未加入团队
+func (s *HintService) GetHints(teamID, challengeID int64) ([]dto.HintResponse, error) {
+	var chal models.Challenge
+	if err := config.DB.First(&chal, challengeID).Error; err != nil {
+		return nil, errors.New("题目不存在或不可见")
+	}
+	if err := NewChallengeService().CheckChallengeAccess(teamID, &chal); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var hints []models.ChallengeHint
+	if err := config.DB.Where("challenge_id = ? AND deleted_at IS NULL", challengeID).
+		Where("release_at IS NULL OR release_at <= ?", now).
+		Order("sort_order ASC, id ASC").
+		Find(&hints).Error; err != nil {
+		return nil, err
+	}
+
+	// 本队已解锁的提示
+	unlocked := make(map[int64]bool)
+	if teamID > 0 {
+		var hintIDs []int64
+		if err := config.DB.Model(&models.HintUnlock{}).
+			Where("team_id = ? AND challenge_id = ?", teamID, challengeID).
+			Pluck("hint_id", &hintIDs).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range hintIDs {
+			unlocked[id] = true
+		}
+	}
+
+	list := make([]dto.HintResponse, 0, len(hints))
+	for i := range hints {
+		hint := &hints[i]
+		item := dto.HintResponse{
+			ID:          hint.ID,
+			ChallengeID: hint.ChallengeID,
+			Cost:        hint.Cost,
+			ReleaseAt:   hint.ReleaseAt,
+			SortOrder:   hint.SortOrder,
+			Unlocked:    hint.IsFree() || unlocked[hint.ID],
+		}
+		if item.Unlocked {
+			item.Content = &hint.Content
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// UnlockHint 解锁提示，扣除团队分数（事务）
+func (s *HintService) UnlockHint(userID, teamID, challengeID, hintID int64) (*dto.HintResponse, error) {
+	hint, err := s.findHint(challengeID, hintID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hint.IsReleased(time.Now()) {
+		return nil, errors.New("提示不存在")
+	}
+
+	var chal models.Challenge
+	if err := config.DB.First(&chal, challengeID).Error; err != nil {
+		return nil, errors.New("题目不存在或不可见")
+	}
+	if err := NewChallengeService().CheckChallengeAccess(teamID, &chal); err != nil {
+		return nil, err
+	}
+
+	resp := &dto.HintResponse{
+		ID:          hint.ID,
+		ChallengeID: hint.ChallengeID,
+		Cost:        hint.Cost,
+		ReleaseAt:   hint.ReleaseAt,
+		SortOrder:   hint.SortOrder,
+		Unlocked:    true,
+		Content:     &hint.Content,
+	}
+
+	// 免费提示无需记录
+	if hint.IsFree() {
+		return resp, nil
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// 锁定团队行，防止并发解锁导致重复扣分
+		var team models.Team
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&team, teamID).Error; err != nil {
+			return err
+		}
+		if !team.IsActive() {
+			return errors.New("团队状态异常，无法解锁提示")
+		}
+
+		var count int64
+		if err := tx.Model(&models.HintUnlock{}).
+			Where("team_id = ? AND hint_id = ?", teamID, hintID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("已解锁该提示")
+		}
+
+		if team.TeamScore < hint.Cost {
+			return errors.New("团队分数不足，无法解锁提示")
+		}
+
+		unlock := &models.HintUnlock{
+			HintID:      hintID,
+			ChallengeID: challengeID,
+			TeamID:      teamID,
+			UserID:      userID,
+			Cost:        hint.Cost,
+		}
+		if err := tx.Create(unlock).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Team{}).Where("id = ?", teamID).
+			UpdateColumn("team_score", gorm.Expr("team_score - ?", hint.Cost)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetAdminHints 获取题目提示及解锁记录（管理员）
+func (s *HintService) GetAdminHints(challengeID int64) ([]dto.AdminHintResponse, error) {
+	var hints []models.ChallengeHint
+	if err := config.DB.Where("challenge_id = ? AND deleted_at IS NULL", challengeID).
+		Order("sort_order ASC, id ASC").
+		Find(&hints).Error; err != nil {
+		return nil, err
+	}
+
+	var unlocks []models.HintUnlock
+	if err := config.DB.Where("challenge_id = ?", challengeID).
+		Order("created_at ASC").
+		Find(&unlocks).Error; err != nil {
+		return nil, err
+	}
+
+	// 批量查询团队名称
+	teamIDs := make([]int64, 0, len(unlocks))
+	for _, u := range unlocks {
+		teamIDs = append(teamIDs, u.TeamID)
+	}
+	teamNames := make(map[int64]string)
+	if len(teamIDs) > 0 {
+		var teams []models.Team
+		if err := config.DB.Select("id, team_name").Where("id IN ?", teamIDs).Find(&teams).Error; err != nil {
+			return nil, err
+		}
+		for _, t := range teams {
+			teamNames[t.ID] = t.TeamName
+		}
+	}
+
+	unlocksByHint := make(map[int64][]dto.HintUnlockInfo)
+	for _, u := range unlocks {
+		unlocksByHint[u.HintID] = append(unlocksByHint[u.HintID], dto.HintUnlockInfo{
+			TeamID:     u.TeamID,
+			TeamName:   teamNames[u.TeamID],
+			UserID:     u.UserID,
+			Cost:       u.Cost,
+			UnlockedAt: u.CreatedAt,
+		})
+	}
+
+	list := make([]dto.AdminHintResponse, 0, len(hints))
+	for _, hint := range hints {
+		records := unlocksByHint[hint.ID]
+		if records == nil {
+			records = []dto.HintUnlockInfo{}
+		}
+		list = append(list, dto.AdminHintResponse{
+			ID:          hint.ID,
+			ChallengeID: hint.ChallengeID,
+			Content:     hint.Content,
+			Cost:        hint.Cost,
+			ReleaseAt:   hint.ReleaseAt,
+			SortOrder:   hint.SortOrder,
+			UnlockCount: len(records),
+			Unlocks:     records,
+			CreatedAt:   hint.CreatedAt,
+			UpdatedAt:   hint.UpdatedAt,
+		})
+	}
+	return list, nil
+}
+
+// findHint 查找题目下未删除的提示
+func (s *HintService) findHint(challengeID, hintID int64) (*models.ChallengeHint, error) {
+	var hint models.ChallengeHint
+	if err := config.DB.Where("challenge_id = ? AND deleted_at IS NULL", challengeID).First(&hint, hintID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("提示不存在")
+		}
+		return nil, err
+	}
+	return &hint, nil
+}
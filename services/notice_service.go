@@ -0,0 +1,334 @@
+package services
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NoticeService 公告服务
+type NoticeService struct{}
+
+// NewNoticeService 创建公告服务实例
+func NewNoticeService() *NoticeService {
+	return &NoticeService{}
+}
+
+// CreateNotice 发布公告（管理员），op 为 nil 表示系统自动发布
+func (s *NoticeService) CreateNotice(op *Operator, req *dto.NoticeRequest) (*models.Notice, error) {
+	if err := s.checkChallenge(req.ChallengeID); err != nil {
+		return nil, err
+	}
+
+	status := req.Status
+	if status == "" {
+		status = "published"
+	}
+
+	var notice *models.Notice
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		notice, err = s.createNotice(tx, op, req, status)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return notice, nil
+}
+
+// createNotice 在事务中创建公告并记录审计日志，op 为 nil 时表示系统自动发布
+func (s *NoticeService) createNotice(tx *gorm.DB, op *Operator, req *dto.NoticeRequest, status string) (*models.Notice, error) {
+	var creatorID int64
+	if op != nil {
+		creatorID = op.UserID
+	}
+
+	notice := &models.Notice{
+		Title:       req.Title,
+		Content:     req.Content,
+		IsTop:       req.IsTop,
+		Status:      status,
+		ChallengeID: req.ChallengeID,
+		CreatedBy:   creatorID,
+	}
+	if err := tx.Create(notice).Error; err != nil {
+		return nil, err
+	}
+	if err := NewAuditService().Record(tx, op, models.AuditNoticeCreate, "notice", notice.ID, nil, notice); err != nil {
+		return nil, err
+	}
+	return notice, nil
+}
+
+// UpdateNotice 修改公告（管理员）
+func (s *NoticeService) UpdateNotice(op *Operator, id int64, req *dto.NoticeRequest) (*models.Notice, error) {
+	notice, err := s.findNotice(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkChallenge(req.ChallengeID); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"title":        req.Title,
+		"content":      req.Content,
+		"is_top":       req.IsTop,
+		"challenge_id": req.ChallengeID,
+	}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+
+	before := *notice
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(notice).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// 重新查询获取最新数据
+		if err := tx.First(notice, id).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditNoticeUpdate, "notice", id, &before, notice)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return notice, nil
+}
+
+// UpdateNoticeTop 置顶/取消置顶（管理员）
+func (s *NoticeService) UpdateNoticeTop(op *Operator, id int64, isTop bool) error {
+	notice, err := s.findNotice(id)
+	if err != nil {
+		return err
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		before := map[string]interface{}{"is_top": notice.IsTop}
+		if err := tx.Model(notice).Update("is_top", isTop).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditNoticeTop, "notice", id, before, map[string]interface{}{"is_top": isTop})
+	})
+}
+
+// DeleteNotice 删除公告（软删除）
+func (s *NoticeService) DeleteNotice(op *Operator, id int64) error {
+	notice, err := s.findNotice(id)
+	if err != nil {
+		return err
+	}
+
+	before := *notice
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(notice).Update("deleted_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditNoticeDelete, "notice", id, &before, nil)
+	})
+}
+
+// GetNoticeList 获取公告列表
+// 非管理员只能看到已发布的公告，且关联题目对其团队不可见时不返回（避免提前泄露题目信息）
+// teamID 为访问者所在团队，未登录或未加入团队为 0
+func (s *NoticeService) GetNoticeList(req *dto.NoticeListRequest, isAdmin bool, teamID int64) (*dto.NoticeListResponse, error) {
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	query, err := s.scopedQuery(isAdmin, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isAdmin && req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.Search != "" {
+		query = query.Where("title LIKE ?", "%"+req.Search+"%")
+	}
+	if req.ChallengeID != nil {
+		query = query.Where("challenge_id = ?", *req.ChallengeID)
+	}
+
+	// 统计总数
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	// 置顶在前，最新在前
+	offset := (req.Page - 1) * req.Limit
+	var notices []models.Notice
+	if err := query.Order("is_top DESC, created_at DESC").
+		Offset(offset).Limit(req.Limit).Find(&notices).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.NoticeResponse, 0, len(notices))
+	for _, notice := range notices {
+		item := dto.NoticeResponse{
+			ID:          notice.ID,
+			Title:       notice.Title,
+			IsTop:       notice.IsTop,
+			ChallengeID: notice.ChallengeID,
+			CreatedAt:   notice.CreatedAt,
+			UpdatedAt:   notice.UpdatedAt,
+		}
+		if isAdmin {
+			item.Status = notice.Status
+		}
+		list = append(list, item)
+	}
+
+	return &dto.NoticeListResponse{
+		Total: int(total),
+		Page:  req.Page,
+		Limit: req.Limit,
+		List:  list,
+	}, nil
+}
+
+// GetNoticeByID 获取公告详情，teamID 为访问者所在团队（未登录或未加入团队为 0）
+func (s *NoticeService) GetNoticeByID(id int64, isAdmin bool, teamID int64) (*dto.NoticeResponse, error) {
+	query, err := s.scopedQuery(isAdmin, teamID)
+	if err != nil {
+		return nil, err
+	}
+	var notice models.Notice
+	if err := query.First(&notice, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("公告不存在")
+		}
+		return nil, err
+	}
+
+	resp := &dto.NoticeResponse{
+		ID:          notice.ID,
+		Title:       notice.Title,
+		Content:     notice.Content,
+		IsTop:       notice.IsTop,
+		ChallengeID: notice.ChallengeID,
+		CreatedAt:   notice.CreatedAt,
+		UpdatedAt:   notice.UpdatedAt,
+	}
+	if isAdmin {
+		resp.Status = notice.Status
+	}
+
+	// 查询发布人
+	var user models.User
+	if err := config.DB.Select("username").First(&user, notice.CreatedBy).Error; err == nil {
+		resp.CreatedByName = user.Username
+	}
+
+	// 查询关联题目名称
+	if notice.ChallengeID != nil {
+		var chal models.Challenge
+		if err := config.DB.Select("challenge_name").First(&chal, *notice.ChallengeID).Error; err == nil {
+			resp.ChallengeName = &chal.ChallengeName
+		}
+	}
+
+	return resp, nil
+}
+
+// GetNoticeVersion 获取公开公告数据版本标识，用于客户端轮询时生成 ETag
+// 版本由该团队可见的公告 ID 及其 updated_at 计算：公告的新增、修改、置顶、删除，
+// 以及关联题目放出、改为可见或前置题目解锁导致的可见范围变化，都会改变版本
+func (s *NoticeService) GetNoticeVersion(teamID int64) (string, error) {
+	query, err := s.scopedQuery(false, teamID)
+	if err != nil {
+		return "", err
+	}
+	var rows []struct {
+		ID        int64
+		UpdatedAt time.Time
+	}
+	if err := query.
+		Select("id, updated_at").
+		Order("id ASC").
+		Scan(&rows).Error; err != nil {
+		return "", err
+	}
+
+	h := sha1.New()
+	for _, row := range rows {
+		fmt.Fprintf(h, "%d:%d;", row.ID, row.UpdatedAt.UnixNano())
+	}
+	return fmt.Sprintf("%d-%x", len(rows), h.Sum(nil)), nil
+}
+
+// scopedQuery 根据身份构建公告查询范围
+// 选手只能看到未关联题目的公告，以及关联题目对其团队可见（已放出、已解锁、赛道允许）的公告
+func (s *NoticeService) scopedQuery(isAdmin bool, teamID int64) (*gorm.DB, error) {
+	query := config.DB.Model(&models.Notice{}).Where("deleted_at IS NULL")
+	if isAdmin {
+		return query, nil
+	}
+
+	chalService := NewChallengeService()
+	challenges := config.DB.Model(&models.Challenge{}).Select("id").
+		Where("state = ? AND deleted_at IS NULL", "visible").
+		Where("release_at IS NULL OR release_at <= ?", time.Now())
+	lockedIDs, err := NewPrerequisiteService().LockedChallengeIDs(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lockedIDs) > 0 {
+		challenges = challenges.Where("id NOT IN ?", lockedIDs)
+	}
+	track, err := chalService.teamTrack(teamID)
+	if err != nil {
+		return nil, err
+	}
+	challenges = chalService.scopeTrack(challenges, track)
+
+	return query.Where("status = ?", "published").
+		Where("challenge_id IS NULL OR challenge_id IN (?)", challenges), nil
+}
+
+// findNotice 查找未删除的公告
+func (s *NoticeService) findNotice(id int64) (*models.Notice, error) {
+	var notice models.Notice
+	if err := config.DB.Where("deleted_at IS NULL").First(&notice, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("公告不存在")
+		}
+		return nil, err
+	}
+	return &notice, nil
+}
+
+// checkChallenge 检查关联题目是否存在
+func (s *NoticeService) checkChallenge(challengeID *int64) error {
+	if challengeID == nil {
+		return nil
+	}
+	var count int64
+	if err := config.DB.Model(&models.Challenge{}).
+		Where("id = ? AND deleted_at IS NULL", *challengeID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("关联的题目不存在")
+	}
+	return nil
+}
@@ -0,0 +1,539 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"isctf/utils"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	// oidcTimeout 与提供方交互的超时时间
+	oidcTimeout = 10 * time.Second
+	// oidcDiscoveryRetry 发现失败后的重试间隔，期间直接返回不可用，避免提供方故障时请求堆积
+	oidcDiscoveryRetry = 30 * time.Second
+)
+
+// oidcDefaultClaims 未配置声明映射时使用的标准声明
+var oidcDefaultClaims = map[string]string{
+	"username":       "preferred_username",
+	"email":          "email",
+	"user_name":      "name",
+	"student_number": "",
+	"school_name":    "",
+}
+
+var (
+	usernamePattern   = regexp.MustCompile(`^[A-Za-z0-9]{3,20}$`)
+	nonAlphanumRegexp = regexp.MustCompile(`[^A-Za-z0-9]`)
+)
+
+// oidcProviders 已完成发现的提供方缓存，oidcDiscoveryFailed 记录最近一次发现失败的时间
+// 发现在锁外进行，同一提供方的并发发现由 oidcDiscovery 合并为一次
+var (
+	oidcProviders       = make(map[string]*oidc.Provider)
+	oidcDiscoveryFailed = make(map[string]time.Time)
+	oidcProvidersMu     sync.Mutex
+	oidcDiscovery       singleflight.Group
+)
+
+// oidcProfile 从 ID Token / UserInfo 中提取的用户信息
+type oidcProfile struct {
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+	UserName      string
+	StudentNumber string
+	SchoolName    string
+}
+
+// OIDCService 统一身份认证服务
+type OIDCService struct{}
+
+// NewOIDCService 创建统一身份认证服务实例
+func NewOIDCService() *OIDCService {
+	return &OIDCService{}
+}
+
+// GetProviders 获取已配置的提供方列表
+func (s *OIDCService) GetProviders() []dto.OIDCProviderResponse {
+	list := make([]dto.OIDCProviderResponse, 0, len(config.AppConfig.OIDC.Providers))
+	for _, p := range config.AppConfig.OIDC.Providers {
+		list = append(list, dto.OIDCProviderResponse{Name: p.Name, DisplayName: p.DisplayName})
+	}
+	return list
+}
+
+// Authorize 发起认证，返回跳转地址与状态 Token
+// linkUserID 非 0 时表示为已登录用户绑定外部身份
+func (s *OIDCService) Authorize(name string, linkUserID int64) (*dto.OIDCAuthorizeResponse, error) {
+	cfg, err := findOIDCProvider(name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, oauthConfig, err := s.client(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	stateToken, err := utils.GenerateOIDCStateToken(utils.OIDCState{
+		Provider:   cfg.Name,
+		State:      state,
+		Nonce:      nonce,
+		Verifier:   verifier,
+		LinkUserID: linkUserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.OIDCAuthorizeResponse{
+		AuthURL:    oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)),
+		StateToken: stateToken,
+	}, nil
+}
+
+// Login 认证回调：已绑定的外部身份直接登录，未绑定时自动注册院校赛道账号
+func (s *OIDCService) Login(name string, req *dto.OIDCCallbackRequest, ip, userAgent string) (*dto.LoginResponse, error) {
+	cfg, state, profile, err := s.exchange(name, req)
+	if err != nil {
+		return nil, err
+	}
+	if state.LinkUserID != 0 {
+		return nil, errors.New("认证状态无效或已过期，请重新发起登录")
+	}
+
+	var identity models.UserIdentity
+	err = config.DB.Where("provider = ? AND subject = ?", cfg.Name, profile.Subject).First(&identity).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var user models.User
+	if err == nil {
+		if err := config.DB.Where("deleted_at IS NULL").First(&user, identity.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("绑定的账号不存在")
+			}
+			return nil, err
+		}
+		config.DB.Model(&identity).Update("last_login_at", time.Now())
+	} else {
+		created, err := s.register(cfg, profile)
+		if err != nil {
+			return nil, err
+		}
+		user = *created
+	}
+
+	if err := checkLoginStatus(&user); err != nil {
+		return nil, err
+	}
+	return NewUserService().beginLogin(&user, ip, userAgent)
+}
+
+// Link 认证回调：为当前登录用户绑定外部身份
+func (s *OIDCService) Link(name string, userID int64, req *dto.OIDCCallbackRequest) error {
+	cfg, state, profile, err := s.exchange(name, req)
+	if err != nil {
+		return err
+	}
+	if state.LinkUserID != userID {
+		return errors.New("认证状态无效或已过期，请重新发起登录")
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.UserIdentity{}).
+			Where("provider = ? AND subject = ?", cfg.Name, profile.Subject).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("该外部身份已绑定其他账号")
+		}
+		if err := tx.Model(&models.UserIdentity{}).
+			Where("provider = ? AND user_id = ?", cfg.Name, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("已绑定该认证提供方")
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:   userID,
+			Provider: cfg.Name,
+			Subject:  profile.Subject,
+			Email:    optionalString(profile.Email),
+		}).Error
+	})
+}
+
+// GetIdentities 获取用户已绑定的外部身份
+func (s *OIDCService) GetIdentities(userID int64) ([]dto.UserIdentityResponse, error) {
+	var identities []models.UserIdentity
+	if err := config.DB.Where("user_id = ?", userID).Order("id ASC").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.UserIdentityResponse, 0, len(identities))
+	for _, i := range identities {
+		displayName := i.Provider
+		if cfg, err := findOIDCProvider(i.Provider); err == nil && cfg.DisplayName != "" {
+			displayName = cfg.DisplayName
+		}
+		list = append(list, dto.UserIdentityResponse{
+			ID:          i.ID,
+			Provider:    i.Provider,
+			DisplayName: displayName,
+			Email:       i.Email,
+			LastLoginAt: i.LastLoginAt,
+			CreatedAt:   i.CreatedAt,
+		})
+	}
+	return list, nil
+}
+
+// Unlink 解除外部身份绑定
+func (s *OIDCService) Unlink(userID, identityID int64) error {
+	result := config.DB.Where("id = ? AND user_id = ?", identityID, userID).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("绑定记录不存在")
+	}
+	return nil
+}
+
+// exchange 校验状态、用授权码换取 Token 并验证 ID Token，返回提取的用户信息
+func (s *OIDCService) exchange(name string, req *dto.OIDCCallbackRequest) (*config.OIDCProviderConfig, *utils.OIDCState, *oidcProfile, error) {
+	cfg, err := findOIDCProvider(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	state, err := utils.ParseOIDCStateToken(req.StateToken)
+	if err != nil || state.Provider != cfg.Name ||
+		subtle.ConstantTimeCompare([]byte(state.State), []byte(req.State)) != 1 {
+		return nil, nil, nil, errors.New("认证状态无效或已过期，请重新发起登录")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcTimeout)
+	defer cancel()
+	provider, oauthConfig, err := s.client(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, req.Code, oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		return nil, nil, nil, errors.New("统一身份认证失败，请重新登录")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, nil, nil, errors.New("统一身份认证失败，请重新登录")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil || subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(state.Nonce)) != 1 {
+		return nil, nil, nil, errors.New("统一身份认证失败，请重新登录")
+	}
+
+	claims := make(map[string]interface{})
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, nil, nil, err
+	}
+	// 部分提供方只在 UserInfo 中返回学号等信息，ID Token 中已有的声明优先
+	if info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token)); err == nil && info.Subject == idToken.Subject {
+		extra := make(map[string]interface{})
+		if err := info.Claims(&extra); err == nil {
+			for k, v := range extra {
+				if _, exists := claims[k]; !exists {
+					claims[k] = v
+				}
+			}
+		}
+	}
+
+	emailVerified, _ := claims["email_verified"].(bool)
+	profile := &oidcProfile{
+		Subject:       idToken.Subject,
+		Username:      mappedClaim(cfg, claims, "username"),
+		Email:         mappedClaim(cfg, claims, "email"),
+		EmailVerified: emailVerified,
+		UserName:      mappedClaim(cfg, claims, "user_name"),
+		StudentNumber: mappedClaim(cfg, claims, "student_number"),
+		SchoolName:    mappedClaim(cfg, claims, "school_name"),
+	}
+	return cfg, state, profile, nil
+}
+
+// register 根据外部身份自动注册院校赛道账号并绑定
+// 受信任提供方直接审核通过；否则尝试匹配学校名单，未命中时保持待审核
+func (s *OIDCService) register(cfg *config.OIDCProviderConfig, profile *oidcProfile) (*models.User, error) {
+	if profile.Email == "" || len(profile.Email) > 100 {
+		return nil, errors.New("认证提供方未返回邮箱，无法自动注册")
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.User{}).Where("email = ?", profile.Email).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("该邮箱已注册，请使用密码登录后在个人中心绑定统一身份认证")
+	}
+
+	school, err := s.resolveSchool(cfg, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := s.pickUsername(cfg, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// 统一身份认证账号不使用平台密码，设置随机密码，需要时可通过找回密码设置
+	password, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:      username,
+		Email:         profile.Email,
+		Role:          "user",
+		Track:         "school",
+		SchoolID:      &school.ID,
+		SchoolName:    &school.SchoolName,
+		UserName:      optionalString(truncateString(profile.UserName, 50)),
+		StudentNumber: optionalString(truncateString(profile.StudentNumber, 50)),
+		EmailVerified: profile.EmailVerified,
+		VerifyStatus:  "pending",
+		Status:        "active",
+	}
+	if err := user.SetPassword(password); err != nil {
+		return nil, err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: cfg.Name,
+			Subject:  profile.Subject,
+			Email:    optionalString(profile.Email),
+		}).Error; err != nil {
+			return err
+		}
+
+		reason := ""
+		if cfg.Trusted {
+			reason = "统一身份认证自动核验通过"
+		} else if profile.StudentNumber != "" && profile.UserName != "" {
+			matched, err := NewSchoolRosterService().MatchAndClaim(tx, school.ID, profile.StudentNumber, profile.UserName, user.ID)
+			if err != nil {
+				return err
+			}
+			if matched {
+				reason = "学校名单自动核验通过"
+			}
+		}
+		if reason == "" {
+			return nil
+		}
+
+		now := time.Now()
+		user.VerifyStatus = "approved"
+		user.VerifyReason = &reason
+		user.VerifiedAt = &now
+		return tx.Model(user).Updates(map[string]interface{}{
+			"verify_status": user.VerifyStatus,
+			"verify_reason": reason,
+			"verified_at":   now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// resolveSchool 确定外部身份所属学校：优先使用提供方固定学校，其次按学校名称声明匹配
+func (s *OIDCService) resolveSchool(cfg *config.OIDCProviderConfig, profile *oidcProfile) (*models.School, error) {
+	query := config.DB.Where("deleted_at IS NULL")
+	switch {
+	case cfg.SchoolID > 0:
+		query = query.Where("id = ?", cfg.SchoolID)
+	case profile.SchoolName != "":
+		query = query.Where("school_name = ?", profile.SchoolName)
+	default:
+		return nil, errors.New("无法确定所属学校，请使用普通注册")
+	}
+
+	var school models.School
+	if err := query.First(&school).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("无法确定所属学校，请使用普通注册")
+		}
+		return nil, err
+	}
+	if school.IsSuspended() {
+		return nil, errors.New("该学校已被封禁，无法注册")
+	}
+	return &school, nil
+}
+
+// pickUsername 选择用户名：声明中的用户名合法且未被占用时直接使用，否则生成随机用户名
+func (s *OIDCService) pickUsername(cfg *config.OIDCProviderConfig, profile *oidcProfile) (string, error) {
+	candidates := make([]string, 0, 6)
+	if usernamePattern.MatchString(profile.Username) {
+		candidates = append(candidates, profile.Username)
+	}
+	prefix := nonAlphanumRegexp.ReplaceAllString(cfg.Name, "")
+	if prefix == "" {
+		prefix = "user"
+	}
+	if len(prefix) > 10 {
+		prefix = prefix[:10]
+	}
+	for i := 0; i < 5; i++ {
+		suffix, err := randomHex(4)
+		if err != nil {
+			return "", err
+		}
+		candidates = append(candidates, prefix+suffix)
+	}
+
+	for _, name := range candidates {
+		var count int64
+		if err := config.DB.Model(&models.User{}).Where("username = ?", name).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return name, nil
+		}
+	}
+	return "", errors.New("生成用户名失败，请重试")
+}
+
+// client 获取提供方（首次使用时执行发现并缓存）及 OAuth2 配置
+func (s *OIDCService) client(cfg *config.OIDCProviderConfig) (*oidc.Provider, *oauth2.Config, error) {
+	provider, err := discoverOIDCProvider(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return provider, &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID, "profile", "email"}, cfg.Scopes...),
+	}, nil
+}
+
+// discoverOIDCProvider 获取已缓存的提供方，未缓存时执行发现
+// 发现失败后 oidcDiscoveryRetry 内不再重试，其他提供方的请求不受影响
+func discoverOIDCProvider(cfg *config.OIDCProviderConfig) (*oidc.Provider, error) {
+	oidcProvidersMu.Lock()
+	provider, ok := oidcProviders[cfg.Name]
+	failedAt, failed := oidcDiscoveryFailed[cfg.Name]
+	oidcProvidersMu.Unlock()
+	if ok {
+		return provider, nil
+	}
+	if failed && time.Since(failedAt) < oidcDiscoveryRetry {
+		return nil, errors.New("统一身份认证服务暂不可用")
+	}
+
+	v, err, _ := oidcDiscovery.Do(cfg.Name, func() (interface{}, error) {
+		// 发现结果由所有等待的请求共享，使用独立的超时而不是某个请求的上下文
+		ctx, cancel := context.WithTimeout(context.Background(), oidcTimeout)
+		defer cancel()
+		provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+
+		oidcProvidersMu.Lock()
+		defer oidcProvidersMu.Unlock()
+		if err != nil {
+			oidcDiscoveryFailed[cfg.Name] = time.Now()
+			return nil, err
+		}
+		oidcProviders[cfg.Name] = provider
+		delete(oidcDiscoveryFailed, cfg.Name)
+		return provider, nil
+	})
+	if err != nil {
+		return nil, errors.New("统一身份认证服务暂不可用")
+	}
+	return v.(*oidc.Provider), nil
+}
+
+// findOIDCProvider 按名称查找提供方配置
+func findOIDCProvider(name string) (*config.OIDCProviderConfig, error) {
+	for i := range config.AppConfig.OIDC.Providers {
+		if config.AppConfig.OIDC.Providers[i].Name == name {
+			return &config.AppConfig.OIDC.Providers[i], nil
+		}
+	}
+	return nil, errors.New("认证提供方不存在")
+}
+
+// mappedClaim 按声明映射读取字符串声明
+func mappedClaim(cfg *config.OIDCProviderConfig, claims map[string]interface{}, field string) string {
+	name, ok := cfg.Claims[field]
+	if !ok {
+		name = oidcDefaultClaims[field]
+	}
+	if name == "" {
+		return ""
+	}
+	value, _ := claims[name].(string)
+	return strings.TrimSpace(value)
+}
+
+// optionalString 空字符串转为 nil
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// randomHex 生成指定字节数的随机十六进制字符串
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"isctf/utils"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockOIDCUser 模拟提供方中的用户
+type mockOIDCUser struct {
+	Subject  string
+	Username string
+	Email    string
+	Name     string
+}
+
+// mockOIDCGrant 授权码对应的授权请求
+type mockOIDCGrant struct {
+	user      mockOIDCUser
+	nonce     string
+	challenge string
+}
+
+// mockOIDCIssuer 本地模拟 OIDC 提供方：发现、授权、Token、UserInfo 与 JWKS
+type mockOIDCIssuer struct {
+	*httptest.Server
+	t      *testing.T
+	key    *rsa.PrivateKey
+	client string
+
+	mu     sync.Mutex
+	user   mockOIDCUser
+	grants map[string]mockOIDCGrant
+	tokens map[string]mockOIDCUser
+}
+
+func newMockOIDCIssuer(t *testing.T, clientID string) *mockOIDCIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDCIssuer{
+		t:      t,
+		key:    key,
+		client: clientID,
+		grants: make(map[string]mockOIDCGrant),
+		tokens: make(map[string]mockOIDCUser),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/userinfo", m.userinfo)
+	mux.HandleFunc("/jwks", m.jwks)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// login 设置下一次授权时登录的用户
+func (m *mockOIDCIssuer) login(user mockOIDCUser) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.user = user
+}
+
+func (m *mockOIDCIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                m.URL,
+		"authorization_endpoint":                m.URL + "/authorize",
+		"token_endpoint":                        m.URL + "/token",
+		"userinfo_endpoint":                     m.URL + "/userinfo",
+		"jwks_uri":                              m.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (m *mockOIDCIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != m.client || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	code, _ := randomHex(16)
+	m.mu.Lock()
+	m.grants[code] = mockOIDCGrant{user: m.user, nonce: q.Get("nonce"), challenge: q.Get("code_challenge")}
+	m.mu.Unlock()
+
+	redirect, _ := url.Parse(q.Get("redirect_uri"))
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *mockOIDCIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	grant, ok := m.grants[r.PostForm.Get("code")]
+	delete(m.grants, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                m.URL,
+		"aud":                m.client,
+		"sub":                grant.user.Subject,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              grant.nonce,
+		"email":              grant.user.Email,
+		"email_verified":     true,
+		"preferred_username": grant.user.Username,
+	})
+	idToken.Header["kid"] = "mock"
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		m.t.Error(err)
+		http.Error(w, "server_error", http.StatusInternalServerError)
+		return
+	}
+
+	accessToken, _ := randomHex(16)
+	m.mu.Lock()
+	m.tokens[accessToken] = grant.user
+	m.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (m *mockOIDCIssuer) userinfo(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	user, ok := m.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	m.mu.Unlock()
+	if !ok {
+		http.Error(w, "invalid_token", http.StatusUnauthorized)
+		return
+	}
+	// 姓名只在 UserInfo 中返回，用于验证声明合并
+	writeJSON(w, map[string]interface{}{"sub": user.Subject, "name": user.Name})
+}
+
+func (m *mockOIDCIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := m.key.PublicKey
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "mock",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// setupOIDCTest 初始化测试配置与 SQLite 数据库，返回模拟提供方与所属学校
+func setupOIDCTest(t *testing.T) (*mockOIDCIssuer, *models.School) {
+	t.Helper()
+	issuer := newMockOIDCIssuer(t, "isctf-test")
+
+	oldConfig := config.AppConfig
+	t.Cleanup(func() {
+		config.AppConfig = oldConfig
+		oidcProvidersMu.Lock()
+		oidcProviders = make(map[string]*oidc.Provider)
+		oidcDiscoveryFailed = make(map[string]time.Time)
+		oidcProvidersMu.Unlock()
+	})
+
+	config.AppConfig = &config.Config{
+		Server: config.ServerConfig{Mode: "test"},
+		JWT: config.JWTConfig{
+			Algorithm:       "HS256",
+			KeyID:           "default",
+			Secret:          strings.Repeat("s", 32),
+			AccessTokenTTL:  60,
+			RefreshTokenTTL: 24,
+		},
+	}
+	utils.InitJWTKeys()
+
+	db := setupTestDB(t, &models.School{}, &models.User{}, &models.UserIdentity{}, &models.UserSession{})
+
+	school := &models.School{SchoolName: "测试大学", Status: "active"}
+	if err := db.Create(school).Error; err != nil {
+		t.Fatal(err)
+	}
+	config.AppConfig.OIDC.Providers = []config.OIDCProviderConfig{{
+		Name:         "mock",
+		DisplayName:  "测试认证",
+		Issuer:       issuer.URL,
+		ClientID:     "isctf-test",
+		ClientSecret: "secret",
+		RedirectURL:  "http://isctf.test/oidc/callback",
+		SchoolID:     school.ID,
+		Trusted:      true,
+	}}
+	return issuer, school
+}
+
+// authorizeOIDC 发起认证并跟随提供方的授权跳转，返回回调参数
+func authorizeOIDC(t *testing.T, s *OIDCService, linkUserID int64) *dto.OIDCCallbackRequest {
+	t.Helper()
+	auth, err := s.Authorize("mock", linkUserID)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(auth.AuthURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("授权请求返回 %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dto.OIDCCallbackRequest{
+		Code:       location.Query().Get("code"),
+		State:      location.Query().Get("state"),
+		StateToken: auth.StateToken,
+	}
+}
+
+func TestOIDCLoginRegistersAndReusesIdentity(t *testing.T) {
+	issuer, school := setupOIDCTest(t)
+	s := NewOIDCService()
+	issuer.login(mockOIDCUser{Subject: "sub-alice", Username: "alice", Email: "alice@example.com", Name: "爱丽丝"})
+
+	resp, err := s.Login("mock", authorizeOIDC(t, s, 0), "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("首次登录: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatal("首次登录未签发 Token")
+	}
+	if resp.UserInfo.Username != "alice" || resp.UserInfo.VerifyStatus != "approved" {
+		t.Fatalf("注册用户不符合预期: %+v", resp.UserInfo)
+	}
+	if resp.UserInfo.SchoolID == nil || *resp.UserInfo.SchoolID != school.ID {
+		t.Fatal("注册用户未关联提供方学校")
+	}
+	if resp.UserInfo.UserName == nil || *resp.UserInfo.UserName != "爱丽丝" {
+		t.Fatal("未合并 UserInfo 中的姓名声明")
+	}
+
+	again, err := s.Login("mock", authorizeOIDC(t, s, 0), "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("再次登录: %v", err)
+	}
+	if again.UserInfo.ID != resp.UserInfo.ID {
+		t.Fatalf("再次登录返回了不同的用户 %d != %d", again.UserInfo.ID, resp.UserInfo.ID)
+	}
+
+	var users, identities int64
+	config.DB.Model(&models.User{}).Count(&users)
+	config.DB.Model(&models.UserIdentity{}).Count(&identities)
+	if users != 1 || identities != 1 {
+		t.Fatalf("用户数 %d、绑定数 %d，期望均为 1", users, identities)
+	}
+}
+
+func TestOIDCLinkExistingUser(t *testing.T) {
+	issuer, _ := setupOIDCTest(t)
+	s := NewOIDCService()
+
+	user := &models.User{Username: "bob", Email: "bob@example.com", Role: "user", Track: "public", VerifyStatus: "approved", Status: "active"}
+	if err := user.SetPassword("password123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.DB.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	issuer.login(mockOIDCUser{Subject: "sub-bob", Username: "bobby", Email: "bob@idp.example.com"})
+
+	// 绑定状态不能用于登录，也不能绑定到其他用户
+	if _, err := s.Login("mock", authorizeOIDC(t, s, user.ID), "127.0.0.1", "test"); err == nil {
+		t.Fatal("绑定状态被用于登录")
+	}
+	if err := s.Link("mock", user.ID+1, authorizeOIDC(t, s, user.ID)); err == nil {
+		t.Fatal("绑定状态被用于其他用户")
+	}
+
+	if err := s.Link("mock", user.ID, authorizeOIDC(t, s, user.ID)); err != nil {
+		t.Fatalf("绑定: %v", err)
+	}
+	resp, err := s.Login("mock", authorizeOIDC(t, s, 0), "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("绑定后登录: %v", err)
+	}
+	if resp.UserInfo.ID != user.ID {
+		t.Fatalf("绑定后登录返回用户 %d，期望 %d", resp.UserInfo.ID, user.ID)
+	}
+}
+
+func TestOIDCRejectsTamperedState(t *testing.T) {
+	issuer, _ := setupOIDCTest(t)
+	s := NewOIDCService()
+	issuer.login(mockOIDCUser{Subject: "sub-eve", Username: "eve", Email: "eve@example.com"})
+
+	req := authorizeOIDC(t, s, 0)
+	req.State = "tampered"
+	if _, err := s.Login("mock", req, "127.0.0.1", "test"); err == nil {
+		t.Fatal("篡改的 state 未被拒绝")
+	}
+
+	// 授权码校验 PKCE：换用另一次认证的状态 Token 时提供方拒绝换取
+	other := authorizeOIDC(t, s, 0)
+	req = authorizeOIDC(t, s, 0)
+	req.State, req.StateToken = other.State, other.StateToken
+	if _, err := s.Login("mock", req, "127.0.0.1", "test"); err == nil {
+		t.Fatal("PKCE 校验未生效")
+	}
+}
+
+func TestOIDCDiscoveryFailureIsCached(t *testing.T) {
+	setupOIDCTest(t)
+	var hits int32
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	cfg := &config.OIDCProviderConfig{Name: "broken", Issuer: broken.URL}
+	for i := 0; i < 3; i++ {
+		if _, err := discoverOIDCProvider(cfg); err == nil {
+			t.Fatal("发现失败时未返回错误")
+		}
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("发现失败后重试了 %d 次，期望只请求 1 次", n)
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"isctf/config"
+	"isctf/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PasswordTokenService 一次性密码令牌服务
+type PasswordTokenService struct{}
+
+// NewPasswordTokenService 创建密码令牌服务实例
+func NewPasswordTokenService() *PasswordTokenService {
+	return &PasswordTokenService{}
+}
+
+// IssueToken 为用户签发一次性令牌，返回令牌明文
+// 同一用途下此前未使用的令牌会被作废
+func (s *PasswordTokenService) IssueToken(userID int64, purpose string, ttl time.Duration) (string, error) {
+	var plain string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		plain, err = s.issueToken(tx, userID, purpose, ttl)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// IssueTokenThrottled 与 IssueToken 相同，但 interval 内已为该用户签发过同一用途的令牌时不再签发，返回空字符串
+// 检查与签发在锁定用户行的同一事务中完成，并发请求不会重复签发
+func (s *PasswordTokenService) IssueTokenThrottled(userID int64, purpose string, ttl, interval time.Duration) (string, error) {
+	var plain string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		var recent int64
+		if err := tx.Model(&models.PasswordToken{}).
+			Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, time.Now().Add(-interval)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent > 0 {
+			return nil
+		}
+
+		var err error
+		plain, err = s.issueToken(tx, userID, purpose, ttl)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// issueToken 在事务中作废同一用途未使用的令牌并签发新令牌
+func (s *PasswordTokenService) issueToken(tx *gorm.DB, userID int64, purpose string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	plain := hex.EncodeToString(buf)
+	now := time.Now()
+
+	if err := tx.Model(&models.PasswordToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error; err != nil {
+		return "", err
+	}
+	if err := tx.Create(&models.PasswordToken{
+		UserID:    userID,
+		TokenHash: hashToken(plain),
+		Purpose:   purpose,
+		ExpiresAt: now.Add(ttl),
+	}).Error; err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// ConsumeToken 使用令牌设置新密码（事务，令牌仅能使用一次），返回对应用户
+func (s *PasswordTokenService) ConsumeToken(plain, purpose, newPassword string) (*models.User, error) {
+	var user models.User
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var token models.PasswordToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND purpose = ?", hashToken(plain), purpose).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("链接无效或已过期")
+			}
+			return err
+		}
+
+		now := time.Now()
+		if !token.IsUsable(now) {
+			return errors.New("链接无效或已过期")
+		}
+
+		if err := tx.Where("deleted_at IS NULL").First(&user, token.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("链接无效或已过期")
+			}
+			return err
+		}
+
+		if err := user.SetPassword(newPassword); err != nil {
+			return err
+		}
+		// 能通过邮件链接设置密码即视为邮箱已验证；同时吊销此前签发的全部 Token
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":           user.Password,
+			"email_verified":     true,
+			"tokens_valid_after": now.Truncate(time.Second),
+		}).Error; err != nil {
+			return err
+		}
+		if err := NewSessionService().RevokeAllSessions(tx, user.ID); err != nil {
+			return err
+		}
+
+		return tx.Model(&token).Update("used_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// hashToken 计算令牌摘要（一次性密码令牌与 Refresh Token 共用）
+func hashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// permissionCacheTTL 角色权限缓存有效期（多实例部署时修改权限最迟在该时间后生效）
+const permissionCacheTTL = 30 * time.Second
+
+// permissionDefinitions 全部权限及说明
+var permissionDefinitions = []dto.PermissionResponse{
+	{Permission: models.PermChallengeWrite, Description: "管理全部题目（含附件、提示、依赖、定时放出）"},
+	{Permission: models.PermChallengeWriteOwn, Description: "仅管理自己创建的题目"},
+	{Permission: models.PermCategoryWrite, Description: "管理题目分类"},
+	{Permission: models.PermNoticeWrite, Description: "管理公告"},
+	{Permission: models.PermTeamBan, Description: "封禁/解封团队"},
+	{Permission: models.PermUserManage, Description: "查看用户、封禁/解封用户"},
+	{Permission: models.PermUserRoleAssign, Description: "修改用户角色（授予管理员仍需超级管理员）"},
+	{Permission: models.PermSchoolManage, Description: "管理学校及学校名单"},
+	{Permission: models.PermStudentReview, Description: "审核、导入学生（院校负责人仅限本校）"},
+	{Permission: models.PermContainerManage, Description: "管理全部题目容器"},
+	{Permission: models.PermAuditView, Description: "查看管理操作审计日志"},
+	{Permission: models.PermPermissionManage, Description: "管理角色权限（仅超级管理员）"},
+}
+
+// assignableRoles 可配置权限的角色，超级管理员固定拥有全部权限
+var assignableRoles = []string{"user", "challenge_author", "school_admin", "admin"}
+
+// permissionCache 角色权限缓存
+var permissionCache struct {
+	sync.RWMutex
+	roles    map[string]map[string]bool
+	loadedAt time.Time
+}
+
+// PermissionService 角色权限服务
+type PermissionService struct{}
+
+// NewPermissionService 创建角色权限服务实例
+func NewPermissionService() *PermissionService {
+	return &PermissionService{}
+}
+
+// HasPermission 检查角色是否拥有任一指定权限
+func (s *PermissionService) HasPermission(role string, permissions ...string) (bool, error) {
+	if role == "super_admin" {
+		return true, nil
+	}
+
+	roles, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	for _, p := range permissions {
+		if roles[role][p] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetRolePermissions 获取权限定义及各角色权限
+func (s *PermissionService) GetRolePermissions() (*dto.RolePermissionsResponse, error) {
+	roles, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.RolePermissionsResponse{
+		Permissions: permissionDefinitions,
+		Roles:       make([]dto.RolePermissionItem, 0, len(assignableRoles)+1),
+	}
+	all := make([]string, 0, len(permissionDefinitions))
+	for _, d := range permissionDefinitions {
+		all = append(all, d.Permission)
+	}
+	resp.Roles = append(resp.Roles, dto.RolePermissionItem{Role: "super_admin", Permissions: all, Editable: false})
+
+	for _, role := range assignableRoles {
+		perms := make([]string, 0, len(roles[role]))
+		for p := range roles[role] {
+			perms = append(perms, p)
+		}
+		sort.Strings(perms)
+		resp.Roles = append(resp.Roles, dto.RolePermissionItem{Role: role, Permissions: perms, Editable: true})
+	}
+	return resp, nil
+}
+
+// SetRolePermissions 覆盖设置角色权限（仅超级管理员）
+func (s *PermissionService) SetRolePermissions(op *Operator, role string, req *dto.SetRolePermissionsRequest) error {
+	if !containsString(assignableRoles, role) {
+		return errors.New("该角色的权限不可修改")
+	}
+
+	valid := make(map[string]bool, len(permissionDefinitions))
+	for _, d := range permissionDefinitions {
+		valid[d.Permission] = true
+	}
+	records := make([]models.RolePermission, 0, len(req.Permissions))
+	granted := make([]string, 0, len(req.Permissions))
+	seen := make(map[string]bool)
+	for _, p := range req.Permissions {
+		if !valid[p] {
+			return errors.New("权限不存在: " + p)
+		}
+		if p == models.PermPermissionManage {
+			return errors.New("权限管理权限仅属于超级管理员")
+		}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		records = append(records, models.RolePermission{Role: role, Permission: p})
+		granted = append(granted, p)
+	}
+	sort.Strings(granted)
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		previous := make([]string, 0)
+		if err := tx.Model(&models.RolePermission{}).Where("role = ?", role).
+			Order("permission ASC").Pluck("permission", &previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role = ?", role).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
+		}
+		return NewAuditService().RecordKey(tx, op, models.AuditRolePermissionsSet, "role", role,
+			map[string]interface{}{"permissions": previous}, map[string]interface{}{"permissions": granted})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// load 读取角色权限（带缓存）
+func (s *PermissionService) load() (map[string]map[string]bool, error) {
+	permissionCache.RLock()
+	if permissionCache.roles != nil && time.Since(permissionCache.loadedAt) < permissionCacheTTL {
+		roles := permissionCache.roles
+		permissionCache.RUnlock()
+		return roles, nil
+	}
+	permissionCache.RUnlock()
+
+	var records []models.RolePermission
+	if err := config.DB.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	roles := make(map[string]map[string]bool)
+	for _, r := range records {
+		if roles[r.Role] == nil {
+			roles[r.Role] = make(map[string]bool)
+		}
+		roles[r.Role][r.Permission] = true
+	}
+
+	permissionCache.Lock()
+	permissionCache.roles = roles
+	permissionCache.loadedAt = time.Now()
+	permissionCache.Unlock()
+	return roles, nil
+}
+
+// invalidate 清除权限缓存
+func (s *PermissionService) invalidate() {
+	permissionCache.Lock()
+	permissionCache.roles = nil
+	permissionCache.Unlock()
+}
+
+// containsString 检查字符串是否在切片中
+func containsString(list []string, target string) bool {
+	for _, s := range list {
+		if s == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"isctf/config"
+	"isctf/models"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrPortPoolExhausted 端口池中已无可用端口
+	ErrPortPoolExhausted = errors.New("端口池已耗尽，请稍后再试")
+	// ErrPortLeaseExpired 启动耗时超过保留时间，未关联的端口租约已被回收
+	ErrPortLeaseExpired = errors.New("容器启动超时，端口租约已失效，请重试")
+)
+
+const (
+	// portLeaseGracePeriod 未关联容器的租约保留时间，超时视为启动中断遗留
+	portLeaseGracePeriod = 5 * time.Minute
+	// portAllocateMaxConflicts 并发分配冲突的最大重试次数
+	portAllocateMaxConflicts = 10
+)
+
+// PortPoolService 宿主机端口池服务，租约记录在数据库中，重启后依然有效
+type PortPoolService struct{}
+
+// NewPortPoolService 创建端口池服务实例
+func NewPortPoolService() *PortPoolService {
+	return &PortPoolService{}
+}
+
+// Allocate 为容器分配 n 个宿主机端口
+// 端口需同时满足：在配置范围内、没有租约、且在绑定地址上当前可监听
+func (s *PortPoolService) Allocate(teamID, challengeID int64, n int) ([]int, error) {
+	if n == 0 {
+		return nil, nil
+	}
+
+	cfg := config.AppConfig.Container
+	var leased []int
+	if err := config.DB.Model(&models.PortLease{}).Pluck("port", &leased).Error; err != nil {
+		return nil, err
+	}
+	used := make(map[int]bool, len(leased))
+	for _, port := range leased {
+		used[port] = true
+	}
+
+	// 从随机位置开始扫描，减少多实例并发时的冲突
+	size := cfg.PortMax - cfg.PortMin + 1
+	start := rand.Intn(size)
+	ports := make([]int, 0, n)
+	conflicts := 0
+	for i := 0; i < size && len(ports) < n; i++ {
+		port := cfg.PortMin + (start+i)%size
+		if used[port] || !portAvailable(cfg.BindIP, port) {
+			continue
+		}
+
+		lease := &models.PortLease{Port: port, TeamID: teamID, ChallengeID: challengeID}
+		if err := config.DB.Create(lease).Error; err != nil {
+			// 唯一索引冲突：端口刚被其他实例占用
+			conflicts++
+			if conflicts > portAllocateMaxConflicts {
+				_ = s.ReleasePorts(ports)
+				return nil, err
+			}
+			continue
+		}
+		ports = append(ports, port)
+	}
+
+	if len(ports) < n {
+		_ = s.ReleasePorts(ports)
+		return nil, ErrPortPoolExhausted
+	}
+	return ports, nil
+}
+
+// Attach 将已分配的端口关联到容器记录
+// 租约超过保留时间被回收（可能已租给其他容器）时返回 ErrPortLeaseExpired，调用方需销毁该容器
+func (s *PortPoolService) Attach(ports []int, containerID int64) error {
+	if len(ports) == 0 {
+		return nil
+	}
+	result := config.DB.Model(&models.PortLease{}).
+		Where("port IN ? AND container_id = 0", ports).
+		Update("container_id", containerID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(ports)) {
+		return ErrPortLeaseExpired
+	}
+	return nil
+}
+
+// Release 释放容器占用的全部端口
+func (s *PortPoolService) Release(containerID int64) error {
+	return config.DB.Where("container_id = ?", containerID).Delete(&models.PortLease{}).Error
+}
+
+// ReleasePorts 释放指定端口（用于启动失败时回滚）
+func (s *PortPoolService) ReleasePorts(ports []int) error {
+	if len(ports) == 0 {
+		return nil
+	}
+	return config.DB.Where("port IN ?", ports).Delete(&models.PortLease{}).Error
+}
+
+// ReleaseStale 释放失效租约：启动中断遗留的未关联租约，以及容器已销毁的租约
+func (s *PortPoolService) ReleaseStale() (int64, error) {
+	result := config.DB.
+		Where("(container_id = 0 AND created_at < ?) OR (container_id <> 0 AND container_id NOT IN (?))",
+			time.Now().Add(-portLeaseGracePeriod),
+			config.DB.Model(&models.Container{}).Select("id").Where("state IN ('running', 'warm', 'stopped') AND deleted_at IS NULL")).
+		Delete(&models.PortLease{})
+	return result.RowsAffected, result.Error
+}
+
+// portAvailable 检查端口在绑定地址上是否可监听，排除被平台以外的进程占用的端口
+func portAvailable(bindIP string, port int) bool {
+	ln, err := net.Listen("tcp", net.JoinHostPort(bindIP, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
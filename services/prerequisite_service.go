@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// PrerequisiteService 题目前置依赖服务
+type PrerequisiteService struct{}
+
+// NewPrerequisiteService 创建前置依赖服务实例
+func NewPrerequisiteService() *PrerequisiteService {
+	return &PrerequisiteService{}
+}
+
+// GetGraph 获取题目依赖图（管理员）
+func (s *PrerequisiteService) GetGraph() (*dto.PrerequisiteGraphResponse, error) {
+	var chals []models.Challenge
+	if err := config.DB.Select("id, challenge_name, direction, state, unlock_score").
+		Where("deleted_at IS NULL").
+		Order("id ASC").
+		Find(&chals).Error; err != nil {
+		return nil, err
+	}
+
+	edges, err := s.loadEdges()
+	if err != nil {
+		return nil, err
+	}
+
+	required := make(map[int64][]int64)
+	edgeList := make([]dto.PrerequisiteEdge, 0, len(edges))
+	for _, e := range edges {
+		required[e.ChallengeID] = append(required[e.ChallengeID], e.RequiredChallengeID)
+		edgeList = append(edgeList, dto.PrerequisiteEdge{
+			ChallengeID:         e.ChallengeID,
+			RequiredChallengeID: e.RequiredChallengeID,
+		})
+	}
+
+	nodes := make([]dto.PrerequisiteNode, 0, len(chals))
+	for _, c := range chals {
+		ids := required[c.ID]
+		if ids == nil {
+			ids = []int64{}
+		}
+		nodes = append(nodes, dto.PrerequisiteNode{
+			ID:                   c.ID,
+			ChallengeName:        c.ChallengeName,
+			Direction:            c.Direction,
+			State:                c.State,
+			UnlockScore:          c.UnlockScore,
+			RequiredChallengeIDs: ids,
+		})
+	}
+
+	return &dto.PrerequisiteGraphResponse{Nodes: nodes, Edges: edgeList}, nil
+}
+
+// SetPrerequisites 设置题目解锁条件（覆盖原有前置题目），保存前校验循环依赖
+func (s *PrerequisiteService) SetPrerequisites(op *Operator, challengeID int64, req *dto.PrerequisiteRequest) (*dto.PrerequisiteNode, error) {
+	var chal models.Challenge
+	if err := config.DB.Where("deleted_at IS NULL").First(&chal, challengeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("题目不存在")
+		}
+		return nil, err
+	}
+
+	// 去重并校验前置题目
+	requiredIDs := make([]int64, 0, len(req.RequiredChallengeIDs))
+	seen := make(map[int64]bool)
+	for _, id := range req.RequiredChallengeIDs {
+		if id == challengeID {
+			return nil, errors.New("题目不能依赖自身")
+		}
+		if !seen[id] {
+			seen[id] = true
+			requiredIDs = append(requiredIDs, id)
+		}
+	}
+	if len(requiredIDs) > 0 {
+		var count int64
+		if err := config.DB.Model(&models.Challenge{}).
+			Where("id IN ? AND deleted_at IS NULL", requiredIDs).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if int(count) != len(requiredIDs) {
+			return nil, errors.New("前置题目不存在")
+		}
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// 以其余题目的依赖关系加上本次设置构建依赖图
+		var edges []models.ChallengePrerequisite
+		if err := tx.Where("challenge_id <> ?", challengeID).
+			Find(&edges).Error; err != nil {
+			return err
+		}
+
+		graph := make(map[int64][]int64)
+		for _, e := range edges {
+			graph[e.ChallengeID] = append(graph[e.ChallengeID], e.RequiredChallengeID)
+		}
+		graph[challengeID] = requiredIDs
+		if hasPrerequisiteCycle(graph, challengeID) {
+			return errors.New("题目依赖存在循环")
+		}
+
+		previousIDs := make([]int64, 0)
+		if err := tx.Model(&models.ChallengePrerequisite{}).Where("challenge_id = ?", challengeID).
+			Order("required_challenge_id ASC").
+			Pluck("required_challenge_id", &previousIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("challenge_id = ?", challengeID).Delete(&models.ChallengePrerequisite{}).Error; err != nil {
+			return err
+		}
+		for _, id := range requiredIDs {
+			if err := tx.Create(&models.ChallengePrerequisite{
+				ChallengeID:         challengeID,
+				RequiredChallengeID: id,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Challenge{}).Where("id = ?", challengeID).
+			UpdateColumn("unlock_score", req.UnlockScore).Error; err != nil {
+			return err
+		}
+
+		sortedIDs := append([]int64(nil), requiredIDs...)
+		sort.Slice(sortedIDs, func(i, j int) bool { return sortedIDs[i] < sortedIDs[j] })
+		return NewAuditService().Record(tx, op, models.AuditChallengePrereq, "challenge", challengeID,
+			map[string]interface{}{"required_challenge_ids": previousIDs, "unlock_score": chal.UnlockScore},
+			map[string]interface{}{"required_challenge_ids": sortedIDs, "unlock_score": req.UnlockScore})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PrerequisiteNode{
+		ID:                   chal.ID,
+		ChallengeName:        chal.ChallengeName,
+		Direction:            chal.Direction,
+		State:                chal.State,
+		UnlockScore:          req.UnlockScore,
+		RequiredChallengeIDs: requiredIDs,
+	}, nil
+}
+
+// LockedChallengeIDs 获取团队尚未解锁的题目ID列表，teamID 为 0 表示未登录或未加入团队
+func (s *PrerequisiteService) LockedChallengeIDs(teamID int64) ([]int64, error) {
+	edges, err := s.loadEdges()
+	if err != nil {
+		return nil, err
+	}
+
+	var scored []models.Challenge
+	if err := config.DB.Select("id, unlock_score").
+		Where("unlock_score > 0 AND deleted_at IS NULL").
+		Find(&scored).Error; err != nil {
+		return nil, err
+	}
+
+	if len(edges) == 0 && len(scored) == 0 {
+		return nil, nil
+	}
+
+	solved, teamScore, err := s.teamProgress(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	locked := make(map[int64]bool)
+	for _, e := range edges {
+		if !solved[e.RequiredChallengeID] {
+			locked[e.ChallengeID] = true
+		}
+	}
+	for _, c := range scored {
+		if teamScore < c.UnlockScore {
+			locked[c.ID] = true
+		}
+	}
+
+	ids := make([]int64, 0, len(locked))
+	for id := range locked {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// IsUnlocked 检查团队是否已满足题目的解锁条件
+func (s *PrerequisiteService) IsUnlocked(teamID int64, chal *models.Challenge) (bool, error) {
+	var requiredIDs []int64
+	if err := config.DB.Model(&models.ChallengePrerequisite{}).
+		Where("challenge_id = ?", chal.ID).
+		Where("required_challenge_id IN (?)", config.DB.Model(&models.Challenge{}).Select("id").Where("deleted_at IS NULL")).
+		Pluck("required_challenge_id", &requiredIDs).Error; err != nil {
+		return false, err
+	}
+
+	if len(requiredIDs) == 0 && chal.UnlockScore <= 0 {
+		return true, nil
+	}
+	if teamID == 0 {
+		return false, nil
+	}
+
+	solved, teamScore, err := s.teamProgress(teamID)
+	if err != nil {
+		return false, err
+	}
+	if teamScore < chal.UnlockScore {
+		return false, nil
+	}
+	for _, id := range requiredIDs {
+		if !solved[id] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// loadEdges 加载全部依赖关系（忽略已删除的前置题目）
+func (s *PrerequisiteService) loadEdges() ([]models.ChallengePrerequisite, error) {
+	var edges []models.ChallengePrerequisite
+	err := config.DB.
+		Where("required_challenge_id IN (?)", config.DB.Model(&models.Challenge{}).Select("id").Where("deleted_at IS NULL")).
+		Order("challenge_id ASC, required_challenge_id ASC").
+		Find(&edges).Error
+	return edges, err
+}
+
+// teamProgress 获取团队已解出的题目集合与当前分数
+func (s *PrerequisiteService) teamProgress(teamID int64) (map[int64]bool, int, error) {
+	solved := make(map[int64]bool)
+	if teamID == 0 {
+		return solved, 0, nil
+	}
+
+	var solvedIDs []int64
+	if err := config.DB.Model(&models.Solve{}).
+		Where("team_id = ?", teamID).
+		Pluck("challenge_id", &solvedIDs).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, id := range solvedIDs {
+		solved[id] = true
+	}
+
+	var team models.Team
+	if err := config.DB.Select("id, team_score").First(&team, teamID).Error; err != nil {
+		return nil, 0, err
+	}
+	return solved, team.TeamScore, nil
+}
+
+// hasPrerequisiteCycle 从 start 出发沿"依赖"方向深度优先搜索，判断能否回到 start
+func hasPrerequisiteCycle(graph map[int64][]int64, start int64) bool {
+	visited := make(map[int64]bool)
+	stack := append([]int64{}, graph[start]...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == start {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, graph[id]...)
+	}
+	return false
+}
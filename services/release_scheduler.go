@@ -0,0 +1,28 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// releaseCheckInterval 定时放出检查间隔
+const releaseCheckInterval = 5 * time.Second
+
+// StartReleaseScheduler 启动题目定时放出调度器（后台协程）
+func StartReleaseScheduler() {
+	chalService := NewChallengeService()
+	go func() {
+		ticker := time.NewTicker(releaseCheckInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			count, err := chalService.ReleaseDueChallenges()
+			if err != nil {
+				fmt.Printf("题目定时放出失败: %v\n", err)
+			}
+			if count > 0 {
+				fmt.Printf("已定时放出 %d 道题目\n", count)
+			}
+		}
+	}()
+}
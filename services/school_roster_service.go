@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchoolRosterService 学校名单服务
+type SchoolRosterService struct{}
+
+// NewSchoolRosterService 创建学校名单服务实例
+func NewSchoolRosterService() *SchoolRosterService {
+	return &SchoolRosterService{}
+}
+
+// AddEntries 批量添加名单条目（管理员），已存在的学号跳过
+func (s *SchoolRosterService) AddEntries(op *Operator, schoolID int64, req *dto.AddRosterRequest) (*dto.AddRosterResponse, error) {
+	if err := s.checkSchool(schoolID); err != nil {
+		return nil, err
+	}
+
+	numbers := make([]string, 0, len(req.Entries))
+	for _, e := range req.Entries {
+		numbers = append(numbers, strings.TrimSpace(e.StudentNumber))
+	}
+
+	var existing []string
+	if err := config.DB.Model(&models.SchoolRoster{}).
+		Where("school_id = ? AND student_number IN ?", schoolID, numbers).
+		Pluck("student_number", &existing).Error; err != nil {
+		return nil, err
+	}
+	skip := make(map[string]bool)
+	for _, n := range existing {
+		skip[n] = true
+	}
+
+	resp := &dto.AddRosterResponse{Skipped: []string{}}
+	entries := make([]models.SchoolRoster, 0, len(req.Entries))
+	for _, e := range req.Entries {
+		number := strings.TrimSpace(e.StudentNumber)
+		if skip[number] {
+			resp.Skipped = append(resp.Skipped, number)
+			continue
+		}
+		skip[number] = true
+		entries = append(entries, models.SchoolRoster{
+			SchoolID:      schoolID,
+			StudentNumber: number,
+			UserName:      strings.TrimSpace(e.UserName),
+			CreatedBy:     op.UserID,
+		})
+	}
+
+	if len(entries) > 0 {
+		added := make([]string, 0, len(entries))
+		for _, e := range entries {
+			added = append(added, e.StudentNumber)
+		}
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.CreateInBatches(&entries, 500).Error; err != nil {
+				return err
+			}
+			return NewAuditService().Record(tx, op, models.AuditRosterAdd, "school", schoolID, nil,
+				map[string]interface{}{"student_numbers": added})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.AddedCount = len(entries)
+	resp.SkippedCount = len(resp.Skipped)
+	return resp, nil
+}
+
+// GetRoster 获取学校名单（管理员）
+func (s *SchoolRosterService) GetRoster(schoolID int64, req *dto.RosterListRequest) (*dto.RosterListResponse, error) {
+	if err := s.checkSchool(schoolID); err != nil {
+		return nil, err
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	query := config.DB.Model(&models.SchoolRoster{}).Where("school_id = ?", schoolID)
+	if req.Search != "" {
+		query = query.Where("user_name LIKE ? OR student_number LIKE ?",
+			"%"+req.Search+"%", "%"+req.Search+"%")
+	}
+	if req.Matched != nil {
+		if *req.Matched {
+			query = query.Where("matched_user_id IS NOT NULL")
+		} else {
+			query = query.Where("matched_user_id IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	var entries []models.SchoolRoster
+	if err := query.Order("id DESC").Offset(offset).Limit(req.Limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.RosterResponse, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, dto.RosterResponse{
+			ID:            e.ID,
+			SchoolID:      e.SchoolID,
+			StudentNumber: e.StudentNumber,
+			UserName:      e.UserName,
+			MatchedUserID: e.MatchedUserID,
+			MatchedAt:     e.MatchedAt,
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+
+	return &dto.RosterListResponse{
+		Total: int(total),
+		Page:  req.Page,
+		Limit: req.Limit,
+		List:  list,
+	}, nil
+}
+
+// DeleteEntry 删除名单条目（管理员），不影响已匹配用户的审核状态
+func (s *SchoolRosterService) DeleteEntry(op *Operator, schoolID, entryID int64) error {
+	var entry models.SchoolRoster
+	if err := config.DB.Where("id = ? AND school_id = ?", entryID, schoolID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("名单条目不存在")
+		}
+		return err
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&entry).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditRosterDelete, "school_roster", entryID, &entry, nil)
+	})
+}
+
+// MatchAndClaim 在事务中匹配学号与姓名，成功则将名单条目标记为该用户所有
+// 每条名单只能匹配一次，防止同一学号被多个账号冒用
+func (s *SchoolRosterService) MatchAndClaim(tx *gorm.DB, schoolID int64, studentNumber, userName string, userID int64) (bool, error) {
+	result := tx.Model(&models.SchoolRoster{}).
+		Where("school_id = ? AND student_number = ? AND user_name = ? AND matched_user_id IS NULL",
+			schoolID, strings.TrimSpace(studentNumber), strings.TrimSpace(userName)).
+		Updates(map[string]interface{}{
+			"matched_user_id": userID,
+			"matched_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// checkSchool 检查学校是否存在
+func (s *SchoolRosterService) checkSchool(schoolID int64) error {
+	var count int64
+	if err := config.DB.Model(&models.School{}).
+		Where("id = ? AND deleted_at IS NULL", schoolID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("学校不存在")
+	}
+	return nil
+}
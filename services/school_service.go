@@ -19,7 +19,7 @@ func NewSchoolService() *SchoolService {
 }
 
 // CreateSchool 创建学校
-func (s *SchoolService) CreateSchool(req *dto.CreateSchoolRequest) (*models.School, error) {
+func (s *SchoolService) CreateSchool(op *Operator, req *dto.CreateSchoolRequest) (*models.School, error) {
 	// 检查学校名称是否已存在
 	var count int64
 	if err := config.DB.Model(&models.School{}).Where("school_name = ?", req.SchoolName).Count(&count).Error; err != nil {
@@ -48,7 +48,13 @@ func (s *SchoolService) CreateSchool(req *dto.CreateSchoolRequest) (*models.Scho
 		Status:      "active",
 	}
 
-	if err := config.DB.Create(school).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(school).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditSchoolCreate, "school", school.ID, nil, school)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -197,7 +203,7 @@ func (s *SchoolService) GetSchoolByName(name string) (*dto.SchoolResponse, error
 }
 
 // UpdateSchool 更新学校信息
-func (s *SchoolService) UpdateSchool(id int64, req *dto.UpdateSchoolRequest) (*models.School, error) {
+func (s *SchoolService) UpdateSchool(op *Operator, id int64, req *dto.UpdateSchoolRequest) (*models.School, error) {
 	var school models.School
 	if err := config.DB.First(&school, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -235,14 +241,23 @@ func (s *SchoolService) UpdateSchool(id int64, req *dto.UpdateSchoolRequest) (*m
 		updates["school_admin"] = *req.SchoolAdmin
 	}
 
-	if len(updates) > 0 {
-		if err := config.DB.Model(&school).Updates(updates).Error; err != nil {
-			return nil, err
-		}
+	if len(updates) == 0 {
+		return &school, nil
 	}
 
-	// 重新查询获取最新数据
-	if err := config.DB.First(&school, id).Error; err != nil {
+	before := school
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&school).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// 重新查询获取最新数据
+		if err := tx.First(&school, id).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditSchoolUpdate, "school", id, &before, &school)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -250,7 +265,7 @@ func (s *SchoolService) UpdateSchool(id int64, req *dto.UpdateSchoolRequest) (*m
 }
 
 // UpdateSchoolStatus 更新学校状态
-func (s *SchoolService) UpdateSchoolStatus(id int64, status string) error {
+func (s *SchoolService) UpdateSchoolStatus(op *Operator, id int64, status string) error {
 	var school models.School
 	if err := config.DB.First(&school, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -259,15 +274,17 @@ func (s *SchoolService) UpdateSchoolStatus(id int64, status string) error {
 		return err
 	}
 
-	if err := config.DB.Model(&school).Update("status", status).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		before := map[string]interface{}{"status": school.Status}
+		if err := tx.Model(&school).Update("status", status).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditSchoolStatus, "school", id, before, map[string]interface{}{"status": status})
+	})
 }
 
 // DeleteSchool 删除学校（软删除）
-func (s *SchoolService) DeleteSchool(id int64) error {
+func (s *SchoolService) DeleteSchool(op *Operator, id int64) error {
 	var school models.School
 	if err := config.DB.First(&school, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -286,9 +303,11 @@ func (s *SchoolService) DeleteSchool(id int64) error {
 	}
 
 	// 软删除
-	if err := config.DB.Delete(&school).Error; err != nil {
-		return err
-	}
-
-	return nil
+	before := school
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&school).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditSchoolDelete, "school", id, &before, nil)
+	})
 }
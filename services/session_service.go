@@ -0,0 +1,199 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"isctf/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SessionService 登录会话服务
+type SessionService struct{}
+
+// NewSessionService 创建会话服务实例
+func NewSessionService() *SessionService {
+	return &SessionService{}
+}
+
+// CreateSession 创建登录会话，签发 Access Token 与 Refresh Token
+// twoFactor 表示本次登录是否通过了两步验证
+func (s *SessionService) CreateSession(user *models.User, ip, userAgent string, twoFactor bool) (*dto.TokenPairResponse, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        truncateString(userAgent, 255),
+		IP:               ip,
+		ExpiresAt:        now.Add(refreshTokenTTL()),
+		LastUsedAt:       now,
+		TwoFactor:        twoFactor,
+	}
+	if err := config.DB.Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	return s.buildTokenPair(user, session, refreshToken)
+}
+
+// Refresh 使用 Refresh Token 换取新的 Token 对，并轮换 Refresh Token
+// 已轮换的旧 Refresh Token 再次出现视为泄露，直接吊销该会话
+func (s *SessionService) Refresh(refreshToken, ip, userAgent string) (*dto.TokenPairResponse, error) {
+	hash := hashToken(refreshToken)
+	newToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var session models.UserSession
+	var user models.User
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refresh_token_hash = ?", hash).
+			First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 检测旧 Token 重放
+			if err := tx.Model(&models.UserSession{}).
+				Where("previous_token_hash = ? AND revoked_at IS NULL", hash).
+				Update("revoked_at", now).Error; err != nil {
+				return err
+			}
+			return errors.New("登录状态已失效，请重新登录")
+		}
+		if err != nil {
+			return err
+		}
+		if !session.IsActive(now) {
+			return errors.New("登录状态已失效，请重新登录")
+		}
+
+		// 用户被封禁或删除时吊销会话
+		if err := tx.Where("deleted_at IS NULL").First(&user, session.UserID).Error; err != nil || !user.IsActive() {
+			if err := tx.Model(&session).Update("revoked_at", now).Error; err != nil {
+				return err
+			}
+			return errors.New("登录状态已失效，请重新登录")
+		}
+
+		session.PreviousTokenHash = &hash
+		session.RefreshTokenHash = hashToken(newToken)
+		session.ExpiresAt = now.Add(refreshTokenTTL())
+		session.LastUsedAt = now
+		session.IP = ip
+		session.UserAgent = truncateString(userAgent, 255)
+		return tx.Model(&session).Updates(map[string]interface{}{
+			"previous_token_hash": hash,
+			"refresh_token_hash":  session.RefreshTokenHash,
+			"expires_at":          session.ExpiresAt,
+			"last_used_at":        now,
+			"ip":                  session.IP,
+			"user_agent":          session.UserAgent,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildTokenPair(&user, &session, newToken)
+}
+
+// ListSessions 获取用户的有效会话列表
+func (s *SessionService) ListSessions(userID, currentSessionID int64) ([]dto.SessionResponse, error) {
+	var sessions []models.UserSession
+	if err := config.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, dto.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID == currentSessionID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+	return list, nil
+}
+
+// RevokeSession 吊销用户自己的某个会话（退出登录/远程下线）
+func (s *SessionService) RevokeSession(userID, sessionID int64) error {
+	result := config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("会话不存在或已失效")
+	}
+	return nil
+}
+
+// RevokeAllSessions 吊销用户全部会话（角色/状态变更、重置密码时调用）
+func (s *SessionService) RevokeAllSessions(tx *gorm.DB, userID int64) error {
+	return tx.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOtherSessions 吊销用户除当前会话外的全部会话
+func (s *SessionService) RevokeOtherSessions(tx *gorm.DB, userID, currentSessionID int64) error {
+	return tx.Model(&models.UserSession{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, currentSessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// buildTokenPair 根据会话签发 Access Token 并组装响应
+func (s *SessionService) buildTokenPair(user *models.User, session *models.UserSession, refreshToken string) (*dto.TokenPairResponse, error) {
+	accessToken, expiresAt, err := utils.GenerateToken(user.ID, user.Username, user.Role, session.ID, session.TwoFactor)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TokenPairResponse{
+		Token:            accessToken,
+		ExpiresAt:        expiresAt.Unix(),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt.Unix(),
+	}, nil
+}
+
+// refreshTokenTTL 获取 Refresh Token 有效期
+func refreshTokenTTL() time.Duration {
+	return time.Duration(config.AppConfig.JWT.RefreshTokenTTL) * time.Hour
+}
+
+// generateRefreshToken 生成随机 Refresh Token 明文
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// truncateString 按字符数截断字符串
+func truncateString(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
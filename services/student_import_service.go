@@ -0,0 +1,418 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"isctf/utils"
+	"net/mail"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxImportRows 单次导入最大行数
+	maxImportRows = 2000
+	// setPasswordTokenTTL 设置密码链接有效期
+	setPasswordTokenTTL = 72 * time.Hour
+)
+
+var (
+	importUsernamePattern      = regexp.MustCompile(`^[A-Za-z0-9]{3,20}$`)
+	importStudentNumberPattern = regexp.MustCompile(`^[A-Za-z0-9]{5,20}$`)
+
+	// importColumnAliases 表头别名（支持中英文）
+	importColumnAliases = map[string]string{
+		"username":       "username",
+		"用户名":            "username",
+		"email":          "email",
+		"邮箱":             "email",
+		"user_name":      "user_name",
+		"real_name":      "user_name",
+		"姓名":             "user_name",
+		"真实姓名":           "user_name",
+		"student_number": "student_number",
+		"学号":             "student_number",
+		"school_grade":   "school_grade",
+		"grade":          "school_grade",
+		"年级":             "school_grade",
+		"student_nature": "student_nature",
+		"nature":         "student_nature",
+		"学生性质":           "student_nature",
+		"性质":             "student_nature",
+	}
+
+	// importNatureAliases 学生性质别名
+	importNatureAliases = map[string]string{
+		"undergraduate": "undergraduate",
+		"本科":            "undergraduate",
+		"本科生":           "undergraduate",
+		"graduate":      "graduate",
+		"研究生":           "graduate",
+		"硕士":            "graduate",
+		"博士":            "graduate",
+	}
+
+	importRequiredColumns = []string{"username", "email", "user_name", "student_number", "school_grade", "student_nature"}
+)
+
+// StudentImportService 学生批量导入服务
+type StudentImportService struct{}
+
+// NewStudentImportService 创建学生导入服务实例
+func NewStudentImportService() *StudentImportService {
+	return &StudentImportService{}
+}
+
+// importRecord 待导入的一行数据
+type importRecord struct {
+	result *dto.StudentImportRow
+	grade  string
+	nature string
+}
+
+// ImportStudents 批量导入学生（管理员/院校负责人）
+// 导入的学生直接设为审核通过，初始密码随机，可通过邮件中的一次性链接设置密码
+func (s *StudentImportService) ImportStudents(op *Operator, schoolID int64, filename string, file io.Reader, req *dto.StudentImportRequest) (*dto.StudentImportResponse, error) {
+	var school models.School
+	if err := config.DB.Where("deleted_at IS NULL").First(&school, schoolID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("学校不存在")
+		}
+		return nil, err
+	}
+	if err := NewUserService().checkSchoolScope(op.UserID, op.Role, &schoolID); err != nil {
+		return nil, err
+	}
+	if school.IsSuspended() {
+		return nil, errors.New("该学校已被封禁，无法导入")
+	}
+
+	rows, err := s.readRows(filename, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("文件中没有数据行")
+	}
+	if len(rows)-1 > maxImportRows {
+		return nil, fmt.Errorf("单次最多导入 %d 行", maxImportRows)
+	}
+
+	columns, err := s.parseHeader(rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	records := s.validateRows(rows[1:], columns)
+	if err := s.checkExisting(schoolID, records); err != nil {
+		return nil, err
+	}
+
+	resp := &dto.StudentImportResponse{
+		DryRun:    req.DryRun,
+		TotalRows: len(records),
+		Rows:      make([]dto.StudentImportRow, 0, len(records)),
+	}
+
+	valid := make([]*importRecord, 0, len(records))
+	for _, r := range records {
+		if len(r.result.Errors) > 0 {
+			r.result.Status = "invalid"
+			resp.InvalidRows++
+			continue
+		}
+		r.result.Status = "valid"
+		resp.ValidRows++
+		valid = append(valid, r)
+	}
+
+	if !req.DryRun && len(valid) > 0 {
+		if err := s.createUsers(op, &school, valid); err != nil {
+			return nil, err
+		}
+		resp.CreatedCount = len(valid)
+
+		if req.SendEmail {
+			for _, r := range valid {
+				if err := s.sendSetPasswordMail(&school, r.result); err != nil {
+					r.result.Errors = append(r.result.Errors, "设置密码邮件发送失败: "+err.Error())
+					continue
+				}
+				r.result.EmailSent = true
+				resp.EmailSent++
+			}
+		}
+	}
+
+	for _, r := range records {
+		resp.Rows = append(resp.Rows, *r.result)
+	}
+	return resp, nil
+}
+
+// readRows 根据扩展名读取 CSV/XLSX 文件的全部行
+func (s *StudentImportService) readRows(filename string, file io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+		// 去除 Excel 导出 CSV 时附带的 UTF-8 BOM
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("CSV 解析失败: %v", err)
+		}
+		return rows, nil
+	case ".xlsx":
+		f, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("XLSX 解析失败: %v", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("XLSX 文件中没有工作表")
+		}
+		rows, err := f.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("XLSX 解析失败: %v", err)
+		}
+		return rows, nil
+	default:
+		return nil, errors.New("仅支持 CSV 或 XLSX 文件")
+	}
+}
+
+// parseHeader 解析表头，返回字段名到列下标的映射
+func (s *StudentImportService) parseHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		key, ok := importColumnAliases[strings.ToLower(strings.TrimSpace(name))]
+		if ok {
+			columns[key] = i
+		}
+	}
+	missing := make([]string, 0)
+	for _, col := range importRequiredColumns {
+		if _, ok := columns[col]; !ok {
+			missing = append(missing, col)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("缺少必需的列: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// validateRows 逐行校验字段格式及文件内重复
+func (s *StudentImportService) validateRows(rows [][]string, columns map[string]int) []*importRecord {
+	cell := func(row []string, col string) string {
+		idx := columns[col]
+		if idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	seenUsername := make(map[string]int)
+	seenEmail := make(map[string]int)
+	seenStudentNumber := make(map[string]int)
+
+	records := make([]*importRecord, 0, len(rows))
+	for i, row := range rows {
+		lineNo := i + 2 // 第 1 行为表头
+		result := &dto.StudentImportRow{
+			Row:           lineNo,
+			Username:      cell(row, "username"),
+			Email:         strings.ToLower(cell(row, "email")),
+			UserName:      cell(row, "user_name"),
+			StudentNumber: cell(row, "student_number"),
+			Errors:        []string{},
+		}
+		record := &importRecord{
+			result: result,
+			grade:  cell(row, "school_grade"),
+		}
+
+		if !importUsernamePattern.MatchString(result.Username) {
+			result.Errors = append(result.Errors, "用户名需为 3-20 位字母或数字")
+		} else if prev, ok := seenUsername[strings.ToLower(result.Username)]; ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("用户名与第 %d 行重复", prev))
+		} else {
+			seenUsername[strings.ToLower(result.Username)] = lineNo
+		}
+
+		if addr, err := mail.ParseAddress(result.Email); err != nil || addr.Address != result.Email || len(result.Email) > 100 {
+			result.Errors = append(result.Errors, "邮箱格式错误")
+		} else if prev, ok := seenEmail[result.Email]; ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("邮箱与第 %d 行重复", prev))
+		} else {
+			seenEmail[result.Email] = lineNo
+		}
+
+		if n := utf8.RuneCountInString(result.UserName); n < 2 || n > 20 {
+			result.Errors = append(result.Errors, "姓名长度需为 2-20 个字符")
+		}
+
+		if !importStudentNumberPattern.MatchString(result.StudentNumber) {
+			result.Errors = append(result.Errors, "学号需为 5-20 位字母或数字")
+		} else if prev, ok := seenStudentNumber[result.StudentNumber]; ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("学号与第 %d 行重复", prev))
+		} else {
+			seenStudentNumber[result.StudentNumber] = lineNo
+		}
+
+		if n := utf8.RuneCountInString(record.grade); n < 2 || n > 10 {
+			result.Errors = append(result.Errors, "年级长度需为 2-10 个字符")
+		}
+
+		nature, ok := importNatureAliases[strings.ToLower(cell(row, "student_nature"))]
+		if !ok {
+			result.Errors = append(result.Errors, "学生性质需为 undergraduate/graduate（本科/研究生）")
+		}
+		record.nature = nature
+
+		records = append(records, record)
+	}
+	return records
+}
+
+// checkExisting 批量检查用户名、邮箱、本校学号是否已存在
+func (s *StudentImportService) checkExisting(schoolID int64, records []*importRecord) error {
+	usernames := make([]string, 0, len(records))
+	emails := make([]string, 0, len(records))
+	studentNumbers := make([]string, 0, len(records))
+	for _, r := range records {
+		usernames = append(usernames, r.result.Username)
+		emails = append(emails, r.result.Email)
+		studentNumbers = append(studentNumbers, r.result.StudentNumber)
+	}
+
+	var existing []models.User
+	if err := config.DB.Select("id, username, email, school_id, student_number").
+		Where("username IN ? OR email IN ? OR (school_id = ? AND student_number IN ?)",
+			usernames, emails, schoolID, studentNumbers).
+		Find(&existing).Error; err != nil {
+		return err
+	}
+
+	existUsername := make(map[string]bool)
+	existEmail := make(map[string]bool)
+	existStudentNumber := make(map[string]bool)
+	for _, u := range existing {
+		existUsername[strings.ToLower(u.Username)] = true
+		existEmail[strings.ToLower(u.Email)] = true
+		if u.SchoolID != nil && *u.SchoolID == schoolID && u.StudentNumber != nil {
+			existStudentNumber[*u.StudentNumber] = true
+		}
+	}
+
+	for _, r := range records {
+		if existUsername[strings.ToLower(r.result.Username)] {
+			r.result.Errors = append(r.result.Errors, "用户名已存在")
+		}
+		if existEmail[r.result.Email] {
+			r.result.Errors = append(r.result.Errors, "邮箱已被注册")
+		}
+		if existStudentNumber[r.result.StudentNumber] {
+			r.result.Errors = append(r.result.Errors, "该学号已在本校注册")
+		}
+	}
+	return nil
+}
+
+// createUsers 在事务中创建审核通过的学生账户
+func (s *StudentImportService) createUsers(op *Operator, school *models.School, records []*importRecord) error {
+	now := time.Now()
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, r := range records {
+			schoolID := school.ID
+			schoolName := school.SchoolName
+			userName := r.result.UserName
+			studentNumber := r.result.StudentNumber
+			grade := r.grade
+			nature := r.nature
+			verifiedBy := op.UserID
+
+			user := &models.User{
+				Username:      r.result.Username,
+				Email:         r.result.Email,
+				Role:          "user",
+				Track:         "school",
+				SchoolID:      &schoolID,
+				SchoolName:    &schoolName,
+				UserName:      &userName,
+				StudentNumber: &studentNumber,
+				SchoolGrade:   &grade,
+				StudentNature: &nature,
+				EmailVerified: false,
+				VerifyStatus:  "approved", // 学校提供的名单，无需再审核
+				VerifiedBy:    &verifiedBy,
+				VerifiedAt:    &now,
+				Status:        "active",
+			}
+
+			// 随机初始密码，用户需通过设置密码链接登录
+			buf := make([]byte, 24)
+			if _, err := rand.Read(buf); err != nil {
+				return err
+			}
+			if err := user.SetPassword(hex.EncodeToString(buf)); err != nil {
+				return err
+			}
+
+			if err := tx.Create(user).Error; err != nil {
+				return fmt.Errorf("第 %d 行创建失败: %v", r.result.Row, err)
+			}
+			r.result.UserID = &user.ID
+			r.result.Status = "created"
+		}
+
+		if err := tx.Model(&models.School{}).Where("id = ?", school.ID).
+			UpdateColumn("user_count", gorm.Expr("user_count + ?", len(records))).Error; err != nil {
+			return err
+		}
+
+		userIDs := make([]int64, 0, len(records))
+		for _, r := range records {
+			userIDs = append(userIDs, *r.result.UserID)
+		}
+		return NewAuditService().Record(tx, op, models.AuditStudentImport, "school", school.ID, nil, map[string]interface{}{
+			"created_count": len(records),
+			"user_ids":      userIDs,
+		})
+	})
+}
+
+// sendSetPasswordMail 签发设置密码令牌并发送邮件
+func (s *StudentImportService) sendSetPasswordMail(school *models.School, row *dto.StudentImportRow) error {
+	token, err := NewPasswordTokenService().IssueToken(*row.UserID, "set_password", setPasswordTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/set-password?token=%s", strings.TrimRight(config.AppConfig.Mail.SiteURL, "/"), token)
+	body := fmt.Sprintf("%s 同学你好：\n\n%s 已为你开通 ISCTF 平台账号。\n用户名：%s\n\n请在 %d 小时内通过以下链接设置登录密码（链接仅可使用一次）：\n%s\n",
+		row.UserName, school.SchoolName, row.Username, int(setPasswordTokenTTL.Hours()), link)
+
+	return utils.SendMail(row.Email, "ISCTF 账号开通通知", body)
+}
@@ -517,7 +517,7 @@ func (s *TeamService) GetTeamList(req *dto.TeamListRequest) (*dto.TeamListRespon
 }
 
 // UpdateTeamStatus 更新团队状态（管理员）
-func (s *TeamService) UpdateTeamStatus(teamID int64, status string) error {
+func (s *TeamService) UpdateTeamStatus(op *Operator, teamID int64, status string) error {
 	var team models.Team
 	if err := config.DB.First(&team, teamID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -526,11 +526,45 @@ func (s *TeamService) UpdateTeamStatus(teamID int64, status string) error {
 		return err
 	}
 
-	if err := config.DB.Model(&team).Update("status", status).Error; err != nil {
-		return err
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		before := map[string]interface{}{"status": team.Status}
+		if err := tx.Model(&team).Update("status", status).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditTeamStatus, "team", teamID, before, map[string]interface{}{"status": status})
+	})
+}
+
+// rankedTeam 排行榜查询结果（团队信息与计榜分数）
+type rankedTeam struct {
+	models.Team      `gorm:"embedded"`
+	RankScore        int
+	RestrictedSolves int64
+}
+
+// scopeRankScore 为团队查询附加计榜分数并按排名排序
+// 限定赛道题目的得分及其提示解锁花费只计入对应赛道的排行榜，总榜（track 为空）中剔除
+func (s *TeamService) scopeRankScore(query *gorm.DB, track string) *gorm.DB {
+	if track == "" {
+		restricted := config.DB.Table("dalictf_solve AS s").
+			Select("s.team_id, SUM(s.earned_score) AS score, COUNT(*) AS solve_count").
+			Joins("JOIN dalictf_challenge AS c ON c.id = s.challenge_id").
+			Where("c.tracks <> ''").
+			Group("s.team_id")
+		restrictedHints := config.DB.Table("dalictf_hint_unlock AS h").
+			Select("h.team_id, SUM(h.cost) AS cost").
+			Joins("JOIN dalictf_challenge AS c ON c.id = h.challenge_id").
+			Where("c.tracks <> ''").
+			Group("h.team_id")
+		query = query.Select("dalictf_team.*, dalictf_team.team_score - COALESCE(rs.score, 0) + COALESCE(rh.cost, 0) AS rank_score, COALESCE(rs.solve_count, 0) AS restricted_solves").
+			Joins("LEFT JOIN (?) AS rs ON rs.team_id = dalictf_team.id", restricted).
+			Joins("LEFT JOIN (?) AS rh ON rh.team_id = dalictf_team.id", restrictedHints)
+	} else {
+		query = query.Select("dalictf_team.*, dalictf_team.team_score AS rank_score, 0 AS restricted_solves")
 	}
 
-	return nil
+	// 按分数排序，分数相同按最后解题时间排序
+	return query.Order("rank_score DESC, dalictf_team.updated_at ASC")
 }
 
 // GetTeamRank 获取团队排名
@@ -560,13 +594,12 @@ func (s *TeamService) GetTeamRank(req *dto.TeamRankRequest) (*dto.TeamRankRespon
 		return nil, err
 	}
 
-	// 按分数排序，分数相同按最后解题时间排序
-	query = query.Order("team_score DESC, updated_at ASC")
+	var teams []rankedTeam
+	offset := (req.Page - 1) * req.Limit
+	query = s.scopeRankScore(query, req.TeamTrack)
 
 	// 分页查询
-	offset := (req.Page - 1) * req.Limit
-	var teams []models.Team
-	if err := query.Offset(offset).Limit(req.Limit).Find(&teams).Error; err != nil {
+	if err := query.Offset(offset).Limit(req.Limit).Scan(&teams).Error; err != nil {
 		return nil, err
 	}
 
@@ -576,6 +609,7 @@ func (s *TeamService) GetTeamRank(req *dto.TeamRankRequest) (*dto.TeamRankRespon
 		// 查询解题数量
 		var solveCount int64
 		config.DB.Model(&models.Solve{}).Where("team_id = ?", team.ID).Count(&solveCount)
+		solveCount -= team.RestrictedSolves
 
 		// 查询最后解题时间
 		var lastSolve models.Solve
@@ -590,7 +624,7 @@ func (s *TeamService) GetTeamRank(req *dto.TeamRankRequest) (*dto.TeamRankRespon
 			Rank:        offset + i + 1,
 			TeamID:      team.ID,
 			TeamName:    team.TeamName,
-			TeamScore:   team.TeamScore,
+			TeamScore:   team.RankScore,
 			TeamTrack:   team.TeamTrack,
 			SchoolName:  team.SchoolName,
 			MemberCount: team.MemberCount,
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"isctf/models"
+	"testing"
+)
+
+// TestRankScoreExcludesRestrictedHints 限定赛道题目的提示花费与得分一样不计入总榜
+func TestRankScoreExcludesRestrictedHints(t *testing.T) {
+	db := setupTestDB(t, &models.Team{}, &models.Challenge{}, &models.Solve{}, &models.HintUnlock{})
+
+	open := &models.Challenge{ChallengeName: "公开题", Tracks: models.ChallengeTracks{}}
+	restricted := &models.Challenge{ChallengeName: "新生题", Tracks: models.ChallengeTracks{"freshman"}}
+	for _, chal := range []*models.Challenge{open, restricted} {
+		if err := db.Create(chal).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 团队分数 = 公开题 100 + 新生题 200 - 公开题提示 10 - 新生题提示 30
+	team := &models.Team{TeamName: "测试队", TeamTrack: "freshman", TeamScore: 260, Status: "active"}
+	if err := db.Create(team).Error; err != nil {
+		t.Fatal(err)
+	}
+	records := []interface{}{
+		&models.Solve{ChallengeID: restricted.ID, TeamID: team.ID, UserID: 1, EarnedScore: 200},
+		&models.HintUnlock{HintID: 1, ChallengeID: open.ID, TeamID: team.ID, UserID: 1, Cost: 10},
+		&models.HintUnlock{HintID: 2, ChallengeID: restricted.ID, TeamID: team.ID, UserID: 1, Cost: 30},
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := NewTeamService()
+	var overall []rankedTeam
+	if err := svc.scopeRankScore(db.Model(&models.Team{}), "").Scan(&overall).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(overall) != 1 || overall[0].RankScore != 90 || overall[0].RestrictedSolves != 1 {
+		t.Fatalf("总榜 = %+v, want rank_score 90, restricted_solves 1", overall)
+	}
+
+	var track []rankedTeam
+	if err := svc.scopeRankScore(db.Model(&models.Team{}), "freshman").Scan(&track).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(track) != 1 || track[0].RankScore != 260 {
+		t.Fatalf("赛道榜 = %+v, want rank_score 260", track)
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"isctf/config"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 创建临时 SQLite 数据库并建表，替换 config.DB，测试结束后恢复
+func setupTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "isctf.db")+"?_pragma=busy_timeout(5000)"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range models {
+		// SQLite 不支持 MySQL 的 enum/set 列类型与 ON UPDATE 默认值，建表时改写；索引名在 SQLite 中全库唯一，加上表名前缀
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range stmt.Schema.Fields {
+			dataType := strings.ToLower(string(field.DataType))
+			if strings.HasPrefix(dataType, "enum") || strings.HasPrefix(dataType, "set") {
+				field.DataType = "text"
+			}
+			field.DefaultValue, _, _ = strings.Cut(field.DefaultValue, " ON UPDATE")
+			tag := strings.NewReplacer("index:", "index:"+stmt.Schema.Table+"_", "Index:", "Index:"+stmt.Schema.Table+"_").
+				Replace(string(field.Tag))
+			field.Tag = reflect.StructTag(tag)
+		}
+		if err := db.AutoMigrate(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldDB := config.DB
+	config.DB = db
+	t.Cleanup(func() { config.DB = oldDB })
+	return db
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"isctf/config"
+	"isctf/dto"
+	"isctf/models"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	totpIssuer         = "ISCTF"
+	totpPeriod         = 30 // 时间步长（秒）
+	recoveryCodeCount  = 10
+	twoFactorMaxFails  = 5                // 连续失败次数上限
+	twoFactorLockTime  = 15 * time.Minute // 超过上限后的锁定时长
+	recoveryCodeLength = 10
+)
+
+// errTwoFactorCode 验证码错误，需在事务回滚后记录失败次数
+var errTwoFactorCode = errors.New("验证码错误")
+
+// TwoFactorService 两步验证（TOTP）服务
+type TwoFactorService struct{}
+
+// NewTwoFactorService 创建两步验证服务实例
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{}
+}
+
+// GetStatus 获取两步验证状态
+func (s *TwoFactorService) GetStatus(userID int64, sessionVerified bool) (*dto.TwoFactorStatusResponse, error) {
+	user, err := s.getUser(config.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TwoFactorStatusResponse{
+		Enabled:                user.HasTwoFactor(),
+		RecoveryCodesRemaining: len(splitRecoveryCodes(user.TOTPRecoveryCodes)),
+		SessionVerified:        sessionVerified,
+	}, nil
+}
+
+// Setup 生成新的 TOTP 密钥，需调用 Enable 验证后才生效
+func (s *TwoFactorService) Setup(userID int64) (*dto.TwoFactorSetupResponse, error) {
+	user, err := s.getUser(config.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.HasTwoFactor() {
+		return nil, errors.New("两步验证已启用")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Username,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Model(user).Updates(map[string]interface{}{
+		"totp_secret":    key.Secret(),
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorSetupResponse{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+	}, nil
+}
+
+// Enable 校验动态验证码并启用两步验证，返回恢复码明文
+// 当前会话视为已通过两步验证，其余会话全部下线
+func (s *TwoFactorService) Enable(userID, sessionID int64, code string) (*dto.RecoveryCodesResponse, error) {
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := s.lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.HasTwoFactor() {
+			return errors.New("两步验证已启用")
+		}
+		if user.TOTPSecret == nil {
+			return errors.New("请先获取两步验证绑定信息")
+		}
+
+		step, ok := matchTOTP(*user.TOTPSecret, code, 0, time.Now())
+		if !ok {
+			return errTwoFactorCode
+		}
+
+		var hashes string
+		codes, hashes, err = generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled":        true,
+			"totp_recovery_codes": hashes,
+			"totp_last_step":      step,
+			"totp_fail_count":     0,
+			"totp_failed_at":      nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.UserSession{}).
+			Where("id = ? AND user_id = ?", sessionID, userID).
+			Update("two_factor", true).Error; err != nil {
+			return err
+		}
+		return NewSessionService().RevokeOtherSessions(tx, userID, sessionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable 校验密码与验证码后关闭两步验证
+func (s *TwoFactorService) Disable(userID int64, req *dto.DisableTwoFactorRequest) error {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := s.lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.HasTwoFactor() {
+			return errors.New("两步验证未启用")
+		}
+		if !user.CheckPassword(req.Password) {
+			return errors.New("密码错误")
+		}
+		if err := s.verify(tx, user, req.Code); err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":         nil,
+			"totp_enabled":        false,
+			"totp_recovery_codes": nil,
+			"totp_last_step":      0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.UserSession{}).
+			Where("user_id = ?", userID).
+			Update("two_factor", false).Error
+	})
+	return s.recordFailure(userID, err)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID int64, code string) (*dto.RecoveryCodesResponse, error) {
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := s.lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.HasTwoFactor() {
+			return errors.New("两步验证未启用")
+		}
+		if err := s.verify(tx, user, code); err != nil {
+			return err
+		}
+
+		var hashes string
+		codes, hashes, err = generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		return tx.Model(user).Update("totp_recovery_codes", hashes).Error
+	})
+	if err := s.recordFailure(userID, err); err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifyLogin 登录第二步校验验证码（动态验证码或恢复码），返回用户
+func (s *TwoFactorService) VerifyLogin(userID int64, code string) (*models.User, error) {
+	var user *models.User
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = s.lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.HasTwoFactor() {
+			return errors.New("两步验证未启用")
+		}
+		return s.verify(tx, user, code)
+	})
+	if err := s.recordFailure(userID, err); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// verify 在事务中校验验证码：失败锁定、时间步防重放、恢复码一次性使用
+func (s *TwoFactorService) verify(tx *gorm.DB, user *models.User, code string) error {
+	now := time.Now()
+	if user.TOTPFailCount >= twoFactorMaxFails && user.TOTPFailedAt != nil &&
+		now.Sub(*user.TOTPFailedAt) < twoFactorLockTime {
+		return errors.New("验证失败次数过多，请15分钟后重试")
+	}
+
+	code = strings.TrimSpace(code)
+	updates := map[string]interface{}{"totp_fail_count": 0, "totp_failed_at": nil}
+	if step, ok := matchTOTP(*user.TOTPSecret, code, user.TOTPLastStep, now); ok {
+		updates["totp_last_step"] = step
+	} else if remaining, ok := consumeRecoveryCode(user.TOTPRecoveryCodes, code); ok {
+		updates["totp_recovery_codes"] = remaining
+	} else {
+		return errTwoFactorCode
+	}
+
+	return tx.Model(user).Updates(updates).Error
+}
+
+// recordFailure 事务回滚后记录验证码错误次数，超过锁定时长的旧失败记录重新计数
+func (s *TwoFactorService) recordFailure(userID int64, err error) error {
+	if !errors.Is(err, errTwoFactorCode) {
+		return err
+	}
+	if dbErr := config.DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_fail_count": gorm.Expr("IF(totp_failed_at IS NULL OR totp_failed_at < ?, 1, totp_fail_count + 1)",
+			time.Now().Add(-twoFactorLockTime)),
+		"totp_failed_at": time.Now(),
+	}).Error; dbErr != nil {
+		return dbErr
+	}
+	return err
+}
+
+// getUser 获取用户
+func (s *TwoFactorService) getUser(db *gorm.DB, userID int64) (*models.User, error) {
+	var user models.User
+	if err := db.Where("deleted_at IS NULL").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("用户不存在")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// lockUser 加行锁获取用户，串行化同一用户的验证码校验
+func (s *TwoFactorService) lockUser(tx *gorm.DB, userID int64) (*models.User, error) {
+	return s.getUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+}
+
+// matchTOTP 校验动态验证码，允许前后各一个时间步的误差
+// 仅接受大于 lastStep 的时间步，同一验证码不能重复使用
+func matchTOTP(secret, code string, lastStep int64, now time.Time) (int64, bool) {
+	if len(code) != 6 {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for _, step := range []int64{current - 1, current, current + 1} {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totp.GenerateCode(secret, time.Unix(step*totpPeriod, 0))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// generateRecoveryCodes 生成恢复码，返回明文列表及逗号分隔的摘要
+func generateRecoveryCodes() ([]string, string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	buf := make([]byte, recoveryCodeLength)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, "", err
+		}
+		code := make([]byte, recoveryCodeLength)
+		for j, b := range buf {
+			code[j] = alphabet[int(b)%len(alphabet)]
+		}
+		// 分组展示，例如 abcde-fghjk
+		plain := string(code[:5]) + "-" + string(code[5:])
+		codes = append(codes, plain)
+		hashes = append(hashes, hashToken(plain))
+	}
+	return codes, strings.Join(hashes, ","), nil
+}
+
+// consumeRecoveryCode 匹配恢复码，成功时返回移除该恢复码后的摘要列表
+func consumeRecoveryCode(stored *string, code string) (string, bool) {
+	hash := hashToken(strings.ToLower(code))
+	hashes := splitRecoveryCodes(stored)
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			remaining := append(hashes[:i:i], hashes[i+1:]...)
+			return strings.Join(remaining, ","), true
+		}
+	}
+	return "", false
+}
+
+// splitRecoveryCodes 拆分恢复码摘要
+func splitRecoveryCodes(stored *string) []string {
+	if stored == nil || *stored == "" {
+		return nil
+	}
+	return strings.Split(*stored, ",")
+}
@@ -12,8 +12,12 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// passwordResetTokenTTL 重置密码链接有效期
+const passwordResetTokenTTL = 30 * time.Minute
+
 // UserService 用户服务
 type UserService struct{}
 
@@ -119,7 +123,32 @@ func (s *UserService) RegisterSchool(req *dto.RegisterSchoolRequest) (*models.Us
 		return nil, err
 	}
 
-	if err := config.DB.Create(user).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		// 学号与姓名命中学校名单时自动审核通过，否则保持待审核
+		matched, err := NewSchoolRosterService().MatchAndClaim(tx, req.SchoolID, req.StudentNumber, req.UserName, user.ID)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return nil
+		}
+
+		now := time.Now()
+		reason := "学校名单自动核验通过"
+		user.VerifyStatus = "approved"
+		user.VerifyReason = &reason
+		user.VerifiedAt = &now
+		return tx.Model(user).Updates(map[string]interface{}{
+			"verify_status": user.VerifyStatus,
+			"verify_reason": reason,
+			"verified_at":   now,
+		}).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -185,7 +214,7 @@ func (s *UserService) VerifyEmail(req *dto.VerifyEmailRequest) error {
 }
 
 // Login 用户登录
-func (s *UserService) Login(req *dto.LoginRequest, ip string) (*dto.LoginResponse, error) {
+func (s *UserService) Login(req *dto.LoginRequest, ip, userAgent string) (*dto.LoginResponse, error) {
 	var user models.User
 	if err := config.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -199,40 +228,89 @@ func (s *UserService) Login(req *dto.LoginRequest, ip string) (*dto.LoginRespons
 		return nil, errors.New("用户名或密码错误")
 	}
 
+	if err := checkLoginStatus(&user); err != nil {
+		return nil, err
+	}
+
+	return s.beginLogin(&user, ip, userAgent)
+}
+
+// checkLoginStatus 检查用户状态及院校赛道审核状态是否允许登录
+func checkLoginStatus(user *models.User) error {
 	// 检查用户状态
 	if user.Status == "suspended" {
-		return nil, errors.New("用户已被封禁")
+		return errors.New("用户已被封禁")
 	}
 
 	// 联合院校赛道需要检查审核状态
 	if user.Track == "school" && user.VerifyStatus != "approved" {
 		if user.VerifyStatus == "pending" {
-			return nil, errors.New("您的学生信息正在审核中，请等待院校负责人审核")
+			return errors.New("您的学生信息正在审核中，请等待院校负责人审核")
 		}
 		if user.VerifyStatus == "rejected" {
-			return nil, errors.New("您的学生信息审核未通过，请联系院校负责人")
+			return errors.New("您的学生信息审核未通过，请联系院校负责人")
 		}
 	}
+	return nil
+}
+
+// beginLogin 第一步认证（密码/统一身份认证）通过后继续登录
+// 已启用两步验证的账号先签发临时 Token，验证码通过后再创建会话
+func (s *UserService) beginLogin(user *models.User, ip, userAgent string) (*dto.LoginResponse, error) {
+	if user.HasTwoFactor() {
+		twoFactorToken, err := utils.GenerateTwoFactorToken(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.LoginResponse{
+			TwoFactorRequired: true,
+			TwoFactorToken:    twoFactorToken,
+		}, nil
+	}
+
+	return s.completeLogin(user, ip, userAgent, false)
+}
+
+// LoginTwoFactor 登录第二步：校验两步验证码（或恢复码）后完成登录
+func (s *UserService) LoginTwoFactor(req *dto.TwoFactorLoginRequest, ip, userAgent string) (*dto.LoginResponse, error) {
+	userID, err := utils.ParseTwoFactorToken(req.TwoFactorToken)
+	if err != nil {
+		return nil, errors.New("登录已超时，请重新登录")
+	}
 
+	user, err := NewTwoFactorService().VerifyLogin(userID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	// 两步之间账号状态可能发生变化
+	if err := checkLoginStatus(user); err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(user, ip, userAgent, true)
+}
+
+// completeLogin 更新登录信息并创建会话
+func (s *UserService) completeLogin(user *models.User, ip, userAgent string, twoFactor bool) (*dto.LoginResponse, error) {
 	// 更新最后登录时间和IP
 	now := time.Now()
-	config.DB.Model(&user).Updates(map[string]interface{}{
+	config.DB.Model(user).Updates(map[string]interface{}{
 		"last_login_time": now,
 		"last_login_ip":   ip,
 	})
 
-	// 生成JWT Token
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	// 创建登录会话，签发 Access Token 与 Refresh Token
+	pair, err := NewSessionService().CreateSession(user, ip, userAgent, twoFactor)
 	if err != nil {
 		return nil, err
 	}
 
-	// 计算过期时间
-	expiresAt := now.Add(24 * time.Hour).Unix()
-
 	return &dto.LoginResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
+		Token:            pair.Token,
+		ExpiresAt:        pair.ExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
 		UserInfo: dto.UserResponse{
 			ID:            user.ID,
 			Username:      user.Username,
@@ -345,6 +423,51 @@ func (s *UserService) ChangePassword(userID int64, req *dto.ChangePasswordReques
 	return nil
 }
 
+// SetPasswordByToken 通过邮件中的一次性链接设置密码
+func (s *UserService) SetPasswordByToken(req *dto.SetPasswordRequest) error {
+	_, err := NewPasswordTokenService().ConsumeToken(req.Token, "set_password", req.NewPassword)
+	return err
+}
+
+// RequestPasswordReset 申请重置密码，向注册邮箱发送一次性重置链接
+// 无论邮箱是否注册都返回成功，令牌同步签发、邮件异步发送，避免通过响应内容或发信耗时探测邮箱
+func (s *UserService) RequestPasswordReset(req *dto.RequestPasswordResetRequest) error {
+	var user models.User
+	if err := config.DB.Where("email = ? AND status = ? AND deleted_at IS NULL", req.Email, "active").
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// 同一用户 1 分钟内只发送一次，防止邮件轰炸
+	token, err := NewPasswordTokenService().IssueTokenThrottled(user.ID, "reset_password", passwordResetTokenTTL, time.Minute)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return nil
+	}
+
+	go func(user models.User, token string) {
+		link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(config.AppConfig.Mail.SiteURL, "/"), token)
+		body := fmt.Sprintf("%s 你好：\n\n我们收到了重置 ISCTF 平台账号密码的申请。\n请在 %d 分钟内通过以下链接设置新密码（链接仅可使用一次）：\n%s\n\n如果不是你本人操作，请忽略本邮件。\n",
+			user.Username, int(passwordResetTokenTTL.Minutes()), link)
+		if err := utils.SendMail(user.Email, "ISCTF 密码重置", body); err != nil {
+			fmt.Printf("发送重置密码邮件失败: %v\n", err)
+		}
+	}(user, token)
+
+	return nil
+}
+
+// ConfirmPasswordReset 使用重置链接设置新密码，并使该用户已签发的全部 Token 失效
+func (s *UserService) ConfirmPasswordReset(req *dto.ConfirmPasswordResetRequest) error {
+	_, err := NewPasswordTokenService().ConsumeToken(req.Token, "reset_password", req.NewPassword)
+	return err
+}
+
 // GetUserList 获取用户列表（管理员）
 func (s *UserService) GetUserList(req *dto.UserListRequest) (*dto.UserListResponse, error) {
 	// 设置默认值
@@ -436,30 +559,110 @@ func (s *UserService) GetUserList(req *dto.UserListRequest) (*dto.UserListRespon
 	}, nil
 }
 
+// verifyReasonTemplates 驳回理由模板
+var verifyReasonTemplates = []dto.ReasonTemplateResponse{
+	{Key: "info_incomplete", Content: "注册信息不完整，请补充完整后重新提交"},
+	{Key: "student_number_invalid", Content: "学号无法核实，请确认学号填写正确"},
+	{Key: "name_mismatch", Content: "姓名与学号不匹配"},
+	{Key: "grade_invalid", Content: "年级或学生性质填写有误"},
+	{Key: "not_enrolled", Content: "经核实非本校在读学生"},
+}
+
+// GetVerifyReasonTemplates 获取驳回理由模板列表
+func (s *UserService) GetVerifyReasonTemplates() []dto.ReasonTemplateResponse {
+	return verifyReasonTemplates
+}
+
+// buildVerifyReason 根据模板和自定义内容生成审核理由
+func (s *UserService) buildVerifyReason(template string, reason *string) (*string, error) {
+	var content string
+	if template != "" {
+		for _, t := range verifyReasonTemplates {
+			if t.Key == template {
+				content = t.Content
+				break
+			}
+		}
+		if content == "" {
+			return nil, errors.New("驳回理由模板不存在")
+		}
+	}
+	if reason != nil && strings.TrimSpace(*reason) != "" {
+		if content != "" {
+			content += "：" + strings.TrimSpace(*reason)
+		} else {
+			content = strings.TrimSpace(*reason)
+		}
+	}
+	if content == "" {
+		return nil, nil
+	}
+	return &content, nil
+}
+
+// checkSchoolScope 检查操作者能否管理指定学校的学生
+// 拥有 school.manage 权限的角色不受限制；其他角色（院校负责人）只能管理 School.SchoolAdmin 为自己的学校
+func (s *UserService) checkSchoolScope(operatorID int64, operatorRole string, schoolID *int64) error {
+	allSchools, err := NewPermissionService().HasPermission(operatorRole, models.PermSchoolManage)
+	if err != nil {
+		return err
+	}
+	if allSchools {
+		return nil
+	}
+	if schoolID == nil {
+		return errors.New("无权管理其他学校的学生")
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.School{}).
+		Where("id = ? AND school_admin = ? AND deleted_at IS NULL", *schoolID, operatorID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("无权管理其他学校的学生")
+	}
+	return nil
+}
+
 // VerifyStudent 审核学生信息（院校负责人/管理员）
-func (s *UserService) VerifyStudent(userID, verifierID int64, req *dto.VerifyStudentRequest) error {
+func (s *UserService) VerifyStudent(op *Operator, userID int64, req *dto.VerifyStudentRequest) (*dto.StudentReviewResponse, error) {
 	var user models.User
 	if err := config.DB.First(&user, userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("用户不存在")
+			return nil, errors.New("用户不存在")
 		}
-		return err
+		return nil, err
 	}
 
 	// 检查是否是联合院校赛道
 	if user.Track != "school" {
-		return errors.New("该用户不是联合院校赛道，无需审核")
+		return nil, errors.New("该用户不是联合院校赛道，无需审核")
+	}
+
+	// 院校负责人只能审核本校学生
+	if err := s.checkSchoolScope(op.UserID, op.Role, user.SchoolID); err != nil {
+		return nil, err
+	}
+
+	reason, err := s.buildVerifyReason(req.ReasonTemplate, req.VerifyReason)
+	if err != nil {
+		return nil, err
+	}
+	if req.VerifyStatus == "rejected" && reason == nil {
+		return nil, errors.New("驳回时必须填写理由")
 	}
 
 	now := time.Now()
 	updates := map[string]interface{}{
 		"verify_status": req.VerifyStatus,
-		"verified_by":   verifierID,
+		"verified_by":   op.UserID,
 		"verified_at":   now,
 	}
 
-	if req.VerifyReason != nil {
-		updates["verify_reason"] = *req.VerifyReason
+	if reason != nil {
+		updates["verify_reason"] = *reason
 	}
 
 	// 如果被驳回，增加失败次数
@@ -467,25 +670,234 @@ func (s *UserService) VerifyStudent(userID, verifierID int64, req *dto.VerifyStu
 		updates["register_fail_count"] = user.RegisterFailCount + 1
 	}
 
-	if err := config.DB.Model(&user).Updates(updates).Error; err != nil {
-		return err
+	before := user
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		return NewAuditService().Record(tx, op, models.AuditStudentVerify, "user", userID, verifyAuditFields(&before), verifyAuditFields(&user))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &dto.StudentReviewResponse{
+		ID:                user.ID,
+		Username:          user.Username,
+		UserName:          user.UserName,
+		VerifyStatus:      user.VerifyStatus,
+		VerifyReason:      user.VerifyReason,
+		VerifiedBy:        user.VerifiedBy,
+		VerifiedAt:        user.VerifiedAt,
+		RegisterFailCount: user.RegisterFailCount,
+	}, nil
+}
+
+// BatchVerifyStudents 批量审核学生（院校负责人/管理员），逐个审核并返回失败原因
+func (s *UserService) BatchVerifyStudents(op *Operator, req *dto.BatchVerifyStudentRequest) (*dto.BatchVerifyStudentResponse, error) {
+	// 先校验理由，避免部分审核后才发现模板错误
+	reason, err := s.buildVerifyReason(req.ReasonTemplate, req.VerifyReason)
+	if err != nil {
+		return nil, err
+	}
+	if req.VerifyStatus == "rejected" && reason == nil {
+		return nil, errors.New("驳回时必须填写理由")
+	}
+
+	single := &dto.VerifyStudentRequest{
+		VerifyStatus: req.VerifyStatus,
+		VerifyReason: reason,
+	}
+
+	result := &dto.BatchVerifyStudentResponse{
+		SuccessIDs: []int64{},
+		Failed:     []dto.BatchVerifyFailure{},
+	}
+	seen := make(map[int64]bool)
+	for _, userID := range req.UserIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		if _, err := s.VerifyStudent(op, userID, single); err != nil {
+			result.Failed = append(result.Failed, dto.BatchVerifyFailure{
+				UserID: userID,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		result.SuccessIDs = append(result.SuccessIDs, userID)
+	}
+	return result, nil
+}
+
+// verifyAuditFields 审核相关字段，用于记录审计日志
+func verifyAuditFields(user *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"verify_status":       user.VerifyStatus,
+		"verify_reason":       user.VerifyReason,
+		"register_fail_count": user.RegisterFailCount,
+	}
+}
+
+// GetSchoolStudents 获取学校学生列表（院校负责人/管理员）
+func (s *UserService) GetSchoolStudents(schoolID, operatorID int64, operatorRole string, req *dto.SchoolStudentListRequest) (*dto.SchoolStudentListResponse, error) {
+	var school models.School
+	if err := config.DB.Where("deleted_at IS NULL").First(&school, schoolID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("学校不存在")
+		}
+		return nil, err
+	}
+
+	if err := s.checkSchoolScope(operatorID, operatorRole, &schoolID); err != nil {
+		return nil, err
+	}
+
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	query := config.DB.Model(&models.User{}).
+		Where("school_id = ? AND track = ? AND deleted_at IS NULL", schoolID, "school")
+
+	if req.VerifyStatus != "" {
+		query = query.Where("verify_status = ?", req.VerifyStatus)
+	}
+	if req.Search != "" {
+		query = query.Where("user_name LIKE ? OR student_number LIKE ?",
+			"%"+req.Search+"%", "%"+req.Search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	var users []models.User
+	if err := query.Order("created_at DESC").Offset(offset).Limit(req.Limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.SchoolStudentResponse, 0, len(users))
+	for _, user := range users {
+		list = append(list, dto.SchoolStudentResponse{
+			ID:                user.ID,
+			Username:          user.Username,
+			Email:             user.Email,
+			UserName:          user.UserName,
+			StudentNumber:     user.StudentNumber,
+			SchoolGrade:       user.SchoolGrade,
+			StudentNature:     user.StudentNature,
+			VerifyStatus:      user.VerifyStatus,
+			VerifyReason:      user.VerifyReason,
+			VerifiedAt:        user.VerifiedAt,
+			RegisterFailCount: user.RegisterFailCount,
+			Status:            user.Status,
+			CreatedAt:         user.CreatedAt,
+		})
+	}
+
+	return &dto.SchoolStudentListResponse{
+		Total: int(total),
+		Page:  req.Page,
+		Limit: req.Limit,
+		List:  list,
+	}, nil
 }
 
 // UpdateUserRole 更新用户角色（管理员）
-func (s *UserService) UpdateUserRole(userID int64, role string) error {
-	if err := config.DB.Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error; err != nil {
-		return err
+// 授予或撤销管理员/超级管理员角色需要 permission.manage 权限（仅超级管理员）
+func (s *UserService) UpdateUserRole(op *Operator, userID int64, role string) error {
+	if op.UserID == userID {
+		return errors.New("不能修改自己的角色")
 	}
-	return nil
+
+	// 角色变更后吊销该用户全部会话，强制重新登录以获取新权限
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		target, err := s.lockManagedUser(tx, op.Role, userID)
+		if err != nil {
+			return err
+		}
+		if role == "admin" || role == "super_admin" {
+			allowed, err := s.canManageAdmins(op.Role)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return errors.New("仅超级管理员可以授予管理员权限")
+			}
+		}
+
+		before := map[string]interface{}{"role": target.Role}
+		if err := tx.Model(target).Update("role", role).Error; err != nil {
+			return err
+		}
+		if err := NewAuditService().Record(tx, op, models.AuditUserRole, "user", userID, before, map[string]interface{}{"role": role}); err != nil {
+			return err
+		}
+		return NewSessionService().RevokeAllSessions(tx, userID)
+	})
 }
 
 // UpdateUserStatus 更新用户状态（管理员）
-func (s *UserService) UpdateUserStatus(userID int64, status string) error {
-	if err := config.DB.Model(&models.User{}).Where("id = ?", userID).Update("status", status).Error; err != nil {
-		return err
+func (s *UserService) UpdateUserStatus(op *Operator, userID int64, status string) error {
+	if op.UserID == userID {
+		return errors.New("不能修改自己的状态")
 	}
-	return nil
+
+	// 状态变更后吊销该用户全部会话
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		target, err := s.lockManagedUser(tx, op.Role, userID)
+		if err != nil {
+			return err
+		}
+
+		before := map[string]interface{}{"status": target.Status}
+		if err := tx.Model(target).Update("status", status).Error; err != nil {
+			return err
+		}
+		if err := NewAuditService().Record(tx, op, models.AuditUserStatus, "user", userID, before, map[string]interface{}{"status": status}); err != nil {
+			return err
+		}
+		return NewSessionService().RevokeAllSessions(tx, userID)
+	})
+}
+
+// lockManagedUser 加锁获取被操作用户，管理员/超级管理员账号需要 permission.manage 权限（仅超级管理员）才能管理
+func (s *UserService) lockManagedUser(tx *gorm.DB, operatorRole string, userID int64) (*models.User, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("deleted_at IS NULL").
+		First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("用户不存在")
+		}
+		return nil, err
+	}
+	if user.IsAdmin() {
+		allowed, err := s.canManageAdmins(operatorRole)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, errors.New("仅超级管理员可以管理管理员账号")
+		}
+	}
+	return &user, nil
+}
+
+// canManageAdmins 检查角色能否授予、撤销或管理管理员账号
+// 以不可分配的 permission.manage 权限为准，与角色权限管理保持同一门槛
+func (s *UserService) canManageAdmins(role string) (bool, error) {
+	return NewPermissionService().HasPermission(role, models.PermPermissionManage)
 }
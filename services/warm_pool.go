@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"isctf/config"
+	"isctf/models"
+	"isctf/utils"
+	"time"
+)
+
+const (
+	// warmPoolRefillInterval 预热池补充检查间隔
+	warmPoolRefillInterval = 15 * time.Second
+	// warmPoolRefillBatch 每轮最多新建的预热容器数，避免一次性压垮 Docker
+	warmPoolRefillBatch = 10
+	// warmClaimMaxConflicts 并发领取预热容器冲突的最大重试次数
+	warmClaimMaxConflicts = 3
+	// warmFlagPath 分配预热容器时写入 Flag 的文件路径
+	warmFlagPath = "/flag"
+)
+
+// StartWarmPool 启动预热池补充任务（后台协程）
+func StartWarmPool() {
+	chalService := NewChallengeService()
+	go func() {
+		ticker := time.NewTicker(warmPoolRefillInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			created, removed, err := chalService.RefillWarmPools()
+			if err != nil {
+				fmt.Printf("预热池补充失败: %v\n", err)
+				continue
+			}
+			if created > 0 || removed > 0 {
+				fmt.Printf("预热池: 新建 %d 个，清理 %d 个\n", created, removed)
+			}
+		}
+	}()
+}
+
+// RefillWarmPools 将各题目的预热容器数量调整到配置值
+// 题目已删除、改为静态、镜像变更或预热数量调小时，多余的预热容器会被销毁
+// 预热容器同样占用全局容量名额，容量已满或有团队排队时不再补充
+func (s *ChallengeService) RefillWarmPools() (created, removed int, err error) {
+	var challenges []models.Challenge
+	if err := config.DB.
+		Where("mode = 'dynamic' AND warm_pool_size > 0 AND docker_image IS NOT NULL AND docker_image <> '' AND deleted_at IS NULL").
+		Find(&challenges).Error; err != nil {
+		return 0, 0, err
+	}
+	wanted := make(map[int64]*models.Challenge, len(challenges))
+	for i := range challenges {
+		wanted[challenges[i].ID] = &challenges[i]
+	}
+
+	var warm []models.Container
+	if err := config.DB.Where("state = 'warm' AND deleted_at IS NULL").Order("id ASC").Find(&warm).Error; err != nil {
+		return 0, 0, err
+	}
+	counts := make(map[int64]int, len(challenges))
+	for i := range warm {
+		c := &warm[i]
+		chal, ok := wanted[c.ChallengeID]
+		if ok && c.DockerImage == *chal.DockerImage && counts[c.ChallengeID] < chal.WarmPoolSize {
+			counts[c.ChallengeID]++
+			continue
+		}
+		destroyed, err := s.destroyWarmContainer(c)
+		if err != nil {
+			fmt.Printf("清理预热容器 %d 失败: %v\n", c.ID, err)
+			continue
+		}
+		if destroyed {
+			removed++
+		}
+	}
+
+	var waiting int64
+	if err := config.DB.Model(&models.ContainerQueue{}).Where("state = ?", models.QueueWaiting).Count(&waiting).Error; err != nil {
+		return created, removed, err
+	}
+	if waiting > 0 {
+		return created, removed, nil
+	}
+
+	slots := NewContainerSlotService()
+	budget := warmPoolRefillBatch
+	for i := range challenges {
+		chal := &challenges[i]
+		for counts[chal.ID] < chal.WarmPoolSize && budget > 0 {
+			slot, err := slots.Allocate(config.DB, 0)
+			if err != nil {
+				if errors.Is(err, ErrContainerCapacityFull) {
+					return created, removed, nil
+				}
+				return created, removed, err
+			}
+			c, err := s.createContainer(chal, 0, 0, "", nil, "warm")
+			if err != nil {
+				_ = slots.ReleaseSlot(slot.ID)
+				fmt.Printf("创建题目 %d 预热容器失败: %v\n", chal.ID, err)
+				break
+			}
+			if err := slots.Attach(slot.ID, c.ID); err != nil {
+				if stopErr := s.stopContainerInternal(c); stopErr != nil {
+					fmt.Printf("销毁预热容器 %d 失败: %v\n", c.ID, stopErr)
+				}
+				fmt.Printf("关联预热容器 %d 容量名额失败: %v\n", c.ID, err)
+				break
+			}
+			counts[chal.ID]++
+			budget--
+			created++
+		}
+	}
+	return created, removed, nil
+}
+
+// claimWarmContainer 从预热池中领取一个容器分配给团队，并向容器写入 Flag 文件
+// 预热池为空或写入失败时返回 nil，由调用方冷启动
+func (s *ChallengeService) claimWarmContainer(userID, teamID int64, chal *models.Challenge, flag string) *models.Container {
+	if chal.WarmPoolSize <= 0 || chal.DockerImage == nil {
+		return nil
+	}
+
+	for attempt := 0; attempt < warmClaimMaxConflicts; attempt++ {
+		var c models.Container
+		if err := config.DB.
+			Where("challenge_id = ? AND docker_image = ? AND state = 'warm' AND deleted_at IS NULL", chal.ID, *chal.DockerImage).
+			Order("id ASC").First(&c).Error; err != nil {
+			return nil
+		}
+
+		// 乐观锁：仅当容器仍处于预热状态时领取，避免并发请求领到同一个容器
+		now := time.Now()
+		result := config.DB.Model(&models.Container{}).
+			Where("id = ? AND state = 'warm'", c.ID).
+			Updates(map[string]interface{}{
+				"team_id":        teamID,
+				"user_id":        userID,
+				"container_flag": flag,
+				"state":          "running",
+				"start_time":     now,
+				"end_time":       now.Add(1 * time.Hour), // 默认1小时
+			})
+		if result.Error != nil {
+			return nil
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		c.TeamID = teamID
+		c.UserID = userID
+		c.ContainerFlag = flag
+		c.State = "running"
+		c.StartTime = now
+		c.EndTime = now.Add(1 * time.Hour)
+
+		if err := utils.WriteContainerFile(c.ContainerName, warmFlagPath, []byte(flag)); err != nil {
+			fmt.Printf("向预热容器 %d 写入 Flag 失败: %v\n", c.ID, err)
+			_ = s.stopContainerInternal(&c)
+			return nil
+		}
+		if err := config.DB.Model(&models.PortLease{}).
+			Where("container_id = ?", c.ID).
+			Update("team_id", teamID).Error; err != nil {
+			fmt.Printf("更新容器 %d 端口租约失败: %v\n", c.ID, err)
+		}
+		if err := config.DB.Model(&models.ContainerSlot{}).
+			Where("container_id = ?", c.ID).
+			Update("team_id", teamID).Error; err != nil {
+			fmt.Printf("更新容器 %d 容量名额失败: %v\n", c.ID, err)
+		}
+		return &c
+	}
+	return nil
+}
+
+// destroyWarmContainer 销毁未被领取的预热容器，已被领取时不做处理并返回 false
+func (s *ChallengeService) destroyWarmContainer(c *models.Container) (bool, error) {
+	result := config.DB.Model(&models.Container{}).
+		Where("id = ? AND state = 'warm'", c.ID).
+		Update("state", "stopped")
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	NewContainerProxyService().InvalidateTarget(c.ProxyToken)
+	return true, s.destroyStoppedContainer(c)
+}
+
+// pullChallengeImage 在后台预先拉取动态题目镜像（多容器题目为全部服务镜像），避免首次启动容器时同步拉取
+func pullChallengeImage(chal *models.Challenge) {
+	if chal.Mode != "dynamic" {
+		return
+	}
+	id, images := chal.ID, challengeImages(chal)
+	go func() {
+		for _, image := range images {
+			if err := utils.PullImage(image); err != nil {
+				fmt.Printf("拉取题目 %d 镜像 %s 失败: %v\n", id, image, err)
+			}
+		}
+	}()
+}
+
+// drainWarmPool 销毁题目的全部预热容器（服务定义变更后由预热池按新定义重新补充）
+func (s *ChallengeService) drainWarmPool(challengeID int64) {
+	var warm []models.Container
+	if err := config.DB.Where("challenge_id = ? AND state = 'warm' AND deleted_at IS NULL", challengeID).Find(&warm).Error; err != nil {
+		fmt.Printf("查询题目 %d 预热容器失败: %v\n", challengeID, err)
+		return
+	}
+	for i := range warm {
+		if _, err := s.destroyWarmContainer(&warm[i]); err != nil {
+			fmt.Printf("清理预热容器 %d 失败: %v\n", warm[i].ID, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 公告表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `dalictf_notice` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '公告主键ID',
+  `title` VARCHAR(255) NOT NULL COMMENT '公告标题',
+  `content` TEXT NOT NULL COMMENT '公告内容',
+  `is_top` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否置顶',
+  `status` ENUM('published', 'draft', 'archived') NOT NULL DEFAULT 'published' COMMENT '公告状态',
+  `challenge_id` BIGINT(20) DEFAULT NULL COMMENT '关联题目ID（提示/修复公告）',
+  `created_by` BIGINT(20) NOT NULL COMMENT '创建人ID',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted_at` DATETIME DEFAULT NULL COMMENT '软删除时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_is_top` (`is_top`),
+  KEY `idx_challenge_id` (`challenge_id`),
+  KEY `idx_created_at` (`created_at`),
+  KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='系统公告表';
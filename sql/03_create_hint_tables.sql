@@ -0,0 +1,35 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 题目提示与解锁记录表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+-- 1. 题目提示表
+CREATE TABLE IF NOT EXISTS `dalictf_challenge_hint` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '提示主键ID',
+  `challenge_id` BIGINT(20) NOT NULL COMMENT '所属题目ID',
+  `content` TEXT NOT NULL COMMENT '提示内容',
+  `cost` INT(11) NOT NULL DEFAULT 0 COMMENT '解锁扣除分数（0为免费）',
+  `release_at` DATETIME DEFAULT NULL COMMENT '放出时间（NULL为立即可见）',
+  `sort_order` INT(11) NOT NULL DEFAULT 0 COMMENT '排序顺序',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted_at` DATETIME DEFAULT NULL COMMENT '软删除时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_challenge_id` (`challenge_id`),
+  KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='题目提示表';
+
+-- 2. 提示解锁记录表
+CREATE TABLE IF NOT EXISTS `dalictf_hint_unlock` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '解锁记录主键ID',
+  `hint_id` BIGINT(20) NOT NULL COMMENT '提示ID',
+  `challenge_id` BIGINT(20) NOT NULL COMMENT '题目ID',
+  `team_id` BIGINT(20) NOT NULL COMMENT '解锁团队ID',
+  `user_id` BIGINT(20) NOT NULL COMMENT '操作用户ID',
+  `cost` INT(11) NOT NULL COMMENT '实际扣除分数',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '解锁时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_team_hint` (`team_id`, `hint_id`),
+  KEY `idx_challenge_id` (`challenge_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='提示解锁记录表';
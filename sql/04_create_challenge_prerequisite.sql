@@ -0,0 +1,20 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 题目前置依赖与解锁分数
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+-- 1. 题目增加解锁所需团队分数
+ALTER TABLE `dalictf_challenge`
+  ADD COLUMN `unlock_score` INT(11) NOT NULL DEFAULT 0 COMMENT '解锁所需团队分数（0为不限制）' AFTER `solved_count`;
+
+-- 2. 题目前置依赖表
+CREATE TABLE IF NOT EXISTS `dalictf_challenge_prerequisite` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `challenge_id` BIGINT(20) NOT NULL COMMENT '题目ID',
+  `required_challenge_id` BIGINT(20) NOT NULL COMMENT '需先解出的前置题目ID',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_challenge_required` (`challenge_id`, `required_challenge_id`),
+  KEY `idx_required_challenge_id` (`required_challenge_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='题目前置依赖表';
@@ -0,0 +1,10 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 题目定时放出
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+ALTER TABLE `dalictf_challenge`
+  ADD COLUMN `release_at` DATETIME DEFAULT NULL COMMENT '定时放出时间（NULL为不定时）' AFTER `unlock_score`,
+  ADD COLUMN `release_notice` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '放出时是否自动发布公告' AFTER `release_at`,
+  ADD KEY `idx_release_at` (`release_at`);
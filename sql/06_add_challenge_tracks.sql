@@ -0,0 +1,8 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 题目限定赛道
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+ALTER TABLE `dalictf_challenge`
+  ADD COLUMN `tracks` SET('social','freshman','advanced') NOT NULL DEFAULT '' COMMENT '限定赛道（空为全部赛道可见）' AFTER `release_notice`;
@@ -0,0 +1,18 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 一次性密码令牌表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `dalictf_password_token` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `user_id` BIGINT(20) NOT NULL COMMENT '用户ID',
+  `token_hash` CHAR(64) NOT NULL COMMENT '令牌SHA-256摘要（不存明文）',
+  `purpose` ENUM('set_password','reset_password') NOT NULL COMMENT '用途：set_password-导入账号设置密码，reset_password-找回密码',
+  `expires_at` DATETIME NOT NULL COMMENT '过期时间',
+  `used_at` DATETIME DEFAULT NULL COMMENT '使用/作废时间（NULL为未使用）',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_token_hash` (`token_hash`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='一次性密码令牌表';
@@ -0,0 +1,18 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 学校学生名单表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `dalictf_school_roster` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `school_id` BIGINT(20) NOT NULL COMMENT '学校ID',
+  `student_number` VARCHAR(50) NOT NULL COMMENT '学号',
+  `user_name` VARCHAR(50) NOT NULL COMMENT '真实姓名',
+  `matched_user_id` BIGINT(20) DEFAULT NULL COMMENT '已匹配注册的用户ID（每条名单仅可匹配一次）',
+  `matched_at` DATETIME DEFAULT NULL COMMENT '匹配时间',
+  `created_by` BIGINT(20) NOT NULL COMMENT '添加人ID',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_school_student_number` (`school_id`, `student_number`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='学校学生名单表（院校赛道注册自动核验）';
@@ -0,0 +1,8 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 密码重置后吊销已签发 Token
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+ALTER TABLE `dalictf_user`
+  ADD COLUMN `tokens_valid_after` DATETIME DEFAULT NULL COMMENT '早于该时间签发的Token全部失效' AFTER `last_login_ip`;
@@ -0,0 +1,22 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 用户登录会话表（Refresh Token）
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `dalictf_user_session` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '会话ID（写入 Access Token 的 sid）',
+  `user_id` BIGINT(20) NOT NULL COMMENT '用户ID',
+  `refresh_token_hash` CHAR(64) NOT NULL COMMENT '当前 Refresh Token 的SHA-256摘要（不存明文）',
+  `previous_token_hash` CHAR(64) DEFAULT NULL COMMENT '上一次轮换前的摘要，用于发现 Refresh Token 重放',
+  `user_agent` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '登录设备 User-Agent',
+  `ip` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '最近使用IP',
+  `expires_at` DATETIME NOT NULL COMMENT 'Refresh Token 过期时间',
+  `last_used_at` DATETIME NOT NULL COMMENT '最近使用时间',
+  `revoked_at` DATETIME DEFAULT NULL COMMENT '吊销时间（NULL为有效）',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_refresh_token_hash` (`refresh_token_hash`),
+  KEY `idx_previous_token_hash` (`previous_token_hash`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户登录会话表';
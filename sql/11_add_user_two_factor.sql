@@ -0,0 +1,16 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 两步验证（TOTP）
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+ALTER TABLE `dalictf_user`
+  ADD COLUMN `totp_secret` VARCHAR(64) DEFAULT NULL COMMENT 'TOTP 密钥（Base32）' AFTER `tokens_valid_after`,
+  ADD COLUMN `totp_enabled` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已启用两步验证' AFTER `totp_secret`,
+  ADD COLUMN `totp_recovery_codes` TEXT DEFAULT NULL COMMENT '恢复码SHA-256摘要，逗号分隔' AFTER `totp_enabled`,
+  ADD COLUMN `totp_last_step` BIGINT(20) NOT NULL DEFAULT 0 COMMENT '最近一次通过验证的时间步，防止验证码重放' AFTER `totp_recovery_codes`,
+  ADD COLUMN `totp_fail_count` INT(11) NOT NULL DEFAULT 0 COMMENT '两步验证连续失败次数' AFTER `totp_last_step`,
+  ADD COLUMN `totp_failed_at` DATETIME DEFAULT NULL COMMENT '最近一次两步验证失败时间' AFTER `totp_fail_count`;
+
+ALTER TABLE `dalictf_user_session`
+  ADD COLUMN `two_factor` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '登录时是否通过两步验证' AFTER `last_used_at`;
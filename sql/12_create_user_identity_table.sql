@@ -0,0 +1,18 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 统一身份认证（OIDC）外部身份绑定表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `dalictf_user_identity` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `user_id` BIGINT(20) NOT NULL COMMENT '用户ID',
+  `provider` VARCHAR(50) NOT NULL COMMENT '认证提供方标识',
+  `subject` VARCHAR(255) NOT NULL COMMENT '提供方用户唯一标识（sub）',
+  `email` VARCHAR(100) DEFAULT NULL COMMENT '提供方返回的邮箱',
+  `last_login_at` DATETIME DEFAULT NULL COMMENT '最近一次通过该身份登录时间',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '绑定时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_provider_subject` (`provider`, `subject`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='外部身份绑定表';
@@ -0,0 +1,34 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 角色权限表与出题人角色
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `dalictf_role_permission` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `role` VARCHAR(50) NOT NULL COMMENT '角色',
+  `permission` VARCHAR(50) NOT NULL COMMENT '权限标识',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_role_permission` (`role`, `permission`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='角色权限表';
+
+-- 默认权限（超级管理员固定拥有全部权限，无需配置）
+INSERT IGNORE INTO `dalictf_role_permission` (`role`, `permission`) VALUES
+  ('admin', 'challenge.write'),
+  ('admin', 'category.write'),
+  ('admin', 'notice.write'),
+  ('admin', 'team.ban'),
+  ('admin', 'user.manage'),
+  ('admin', 'user.role.assign'),
+  ('admin', 'school.manage'),
+  ('admin', 'student.review'),
+  ('admin', 'container.manage'),
+  ('school_admin', 'student.review'),
+  ('challenge_author', 'challenge.write.own');
+
+ALTER TABLE `dalictf_user`
+  MODIFY COLUMN `role` ENUM('user','challenge_author','school_admin','admin','super_admin') NOT NULL DEFAULT 'user' COMMENT '角色';
+
+ALTER TABLE `dalictf_challenge`
+  ADD COLUMN `created_by` BIGINT(20) NOT NULL DEFAULT 0 COMMENT '创建者用户ID' AFTER `tracks`,
+  ADD KEY `idx_created_by` (`created_by`);
@@ -0,0 +1,27 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 管理操作审计日志表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+-- 只追加：应用不会修改或删除审计记录，生产环境建议仅授予该表 INSERT/SELECT 权限
+CREATE TABLE IF NOT EXISTS `dalictf_audit_log` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `actor_id` BIGINT(20) NOT NULL COMMENT '操作者用户ID（0 为系统）',
+  `actor_name` VARCHAR(50) NOT NULL COMMENT '操作者用户名',
+  `actor_role` VARCHAR(20) NOT NULL COMMENT '操作时的角色',
+  `action` VARCHAR(50) NOT NULL COMMENT '操作类型，如 team.status',
+  `target_type` VARCHAR(30) NOT NULL COMMENT '操作对象类型',
+  `target_id` VARCHAR(64) NOT NULL COMMENT '操作对象ID',
+  `changes` JSON DEFAULT NULL COMMENT '字段变更前后值',
+  `ip` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '操作者IP',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_actor_id` (`actor_id`),
+  KEY `idx_action` (`action`),
+  KEY `idx_target` (`target_type`, `target_id`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='管理操作审计日志表';
+
+INSERT IGNORE INTO `dalictf_role_permission` (`role`, `permission`) VALUES
+  ('admin', 'audit.view');
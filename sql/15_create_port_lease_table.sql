@@ -0,0 +1,44 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 容器实例表与宿主机端口租约表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+-- 容器实例表（此前仅由模型定义，此处补齐建表语句）
+CREATE TABLE IF NOT EXISTS `dalictf_container` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `challenge_id` BIGINT(20) NOT NULL COMMENT '题目ID',
+  `team_id` BIGINT(20) NOT NULL COMMENT '团队ID',
+  `user_id` BIGINT(20) NOT NULL COMMENT '启动者用户ID',
+  `container_name` VARCHAR(255) NOT NULL COMMENT 'Docker 容器ID',
+  `docker_image` VARCHAR(255) NOT NULL COMMENT '镜像',
+  `docker_ports` JSON DEFAULT NULL COMMENT '容器端口',
+  `host_mapping` JSON DEFAULT NULL COMMENT '容器端口到宿主机端口的映射',
+  `container_flag` VARCHAR(500) NOT NULL COMMENT '动态Flag',
+  `state` ENUM('running','stopped','destroyed') NOT NULL DEFAULT 'running' COMMENT '容器状态',
+  `start_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '启动时间',
+  `end_time` DATETIME NOT NULL COMMENT '到期时间',
+  `extended_count` TINYINT NOT NULL DEFAULT 0 COMMENT '续期次数',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted_at` DATETIME DEFAULT NULL COMMENT '软删除时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_challenge_id` (`challenge_id`),
+  KEY `idx_team_id` (`team_id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_state` (`state`),
+  KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='题目容器实例表';
+
+-- 宿主机端口租约：容器销毁时删除，唯一索引保证同一端口不会被重复分配
+CREATE TABLE IF NOT EXISTS `dalictf_port_lease` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `port` INT NOT NULL COMMENT '宿主机端口',
+  `container_id` BIGINT(20) NOT NULL DEFAULT 0 COMMENT '容器ID（0 表示容器尚未创建）',
+  `team_id` BIGINT(20) NOT NULL COMMENT '团队ID',
+  `challenge_id` BIGINT(20) NOT NULL COMMENT '题目ID',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '分配时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_port` (`port`),
+  KEY `idx_container_id` (`container_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='容器宿主机端口租约表';
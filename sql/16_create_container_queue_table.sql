@@ -0,0 +1,22 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 容器启动排队表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `dalictf_container_queue` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID（越小越先出队）',
+  `challenge_id` BIGINT(20) NOT NULL COMMENT '题目ID',
+  `team_id` BIGINT(20) NOT NULL COMMENT '团队ID',
+  `user_id` BIGINT(20) NOT NULL COMMENT '排队用户ID',
+  `state` ENUM('waiting','starting','started','failed','cancelled') NOT NULL DEFAULT 'waiting' COMMENT '排队状态',
+  `container_id` BIGINT(20) NOT NULL DEFAULT 0 COMMENT '启动成功后的容器记录ID',
+  `error` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '启动失败原因',
+  `polled_at` DATETIME NOT NULL COMMENT '最后一次轮询时间',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '排队时间',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_challenge_id` (`challenge_id`),
+  KEY `idx_team_id` (`team_id`),
+  KEY `idx_state` (`state`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='容器启动排队表';
@@ -0,0 +1,9 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 容器反向代理令牌
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+ALTER TABLE `dalictf_container`
+  ADD COLUMN `proxy_token` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '反向代理子域名令牌' AFTER `container_flag`,
+  ADD KEY `idx_proxy_token` (`proxy_token`);
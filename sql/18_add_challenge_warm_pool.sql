@@ -0,0 +1,11 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 题目容器预热池
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+ALTER TABLE `dalictf_challenge`
+  ADD COLUMN `warm_pool_size` INT NOT NULL DEFAULT 0 COMMENT '预热容器数量' AFTER `docker_ports`;
+
+ALTER TABLE `dalictf_container`
+  MODIFY COLUMN `state` ENUM('warm','running','stopped','destroyed') NOT NULL DEFAULT 'running' COMMENT '容器状态';
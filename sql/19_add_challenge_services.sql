@@ -0,0 +1,12 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 多容器题目
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+ALTER TABLE `dalictf_challenge`
+  ADD COLUMN `services` JSON DEFAULT NULL COMMENT '多容器服务定义' AFTER `docker_ports`;
+
+ALTER TABLE `dalictf_container`
+  ADD COLUMN `network_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '多容器题目的私有网络 ID' AFTER `container_name`,
+  ADD COLUMN `members` JSON DEFAULT NULL COMMENT '多容器题目的全部服务容器' AFTER `network_id`;
@@ -0,0 +1,25 @@
+-- ===========================================
+-- ISCTF 数据库迁移 - 容器容量名额表
+-- ===========================================
+
+SET NAMES utf8mb4;
+
+-- 容器容量名额：启动前占用、容器销毁时删除，唯一索引保证多实例部署时不超出全局容量
+CREATE TABLE IF NOT EXISTS `dalictf_container_slot` (
+  `id` BIGINT(20) NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `slot` INT NOT NULL COMMENT '名额编号（1 到全局容量上限）',
+  `container_id` BIGINT(20) NOT NULL DEFAULT 0 COMMENT '容器ID（0 表示启动中、容器尚未创建）',
+  `team_id` BIGINT(20) NOT NULL DEFAULT 0 COMMENT '团队ID（预热容器为 0）',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '占用时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_slot` (`slot`),
+  KEY `idx_container_id` (`container_id`),
+  KEY `idx_team_id` (`team_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='容器容量名额表';
+
+-- 为已在运行及预热中的容器补齐名额
+INSERT INTO `dalictf_container_slot` (`slot`, `container_id`, `team_id`)
+SELECT ROW_NUMBER() OVER (ORDER BY `id`), `id`, `team_id`
+FROM `dalictf_container`
+WHERE `state` IN ('running', 'warm') AND `deleted_at` IS NULL
+  AND `id` NOT IN (SELECT `container_id` FROM `dalictf_container_slot`);
@@ -1,20 +1,41 @@
 package utils
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"math/rand"
+	"path"
+	"strconv"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
 )
 
+// 平台容器标签，用于在 Docker 中识别由本平台创建的容器
+const (
+	ContainerLabelManaged   = "isctf.managed"      // 固定为 "true"
+	ContainerLabelTeam      = "isctf.team_id"      // 所属团队 ID
+	ContainerLabelChallenge = "isctf.challenge_id" // 所属题目 ID
+)
+
 var dockerClient *client.Client
 
+// DockerContainerInfo 平台容器在 Docker 中的概要信息
+type DockerContainerInfo struct {
+	ID      string
+	State   string // running, exited, dead 等
+	Labels  map[string]string
+	Created time.Time
+}
+
 // InitDocker 初始化 Docker 客户端
 func InitDocker() error {
 	var err error
@@ -31,67 +52,99 @@ func EnsureDockerClient() error {
 	return nil
 }
 
+// ContainerOptions 启动容器参数
+type ContainerOptions struct {
+	Image     string
+	Ports     map[string]string // 容器内部端口 -> 协议 (例如 {"80": "tcp"})
+	HostPorts map[string]int    // 容器内部端口 -> 宿主机端口，未指定的端口由 Docker 随机分配
+	HostIP    string            // 端口绑定的宿主机地址，为空时绑定 0.0.0.0
+	HostIPs   map[string]string // 容器内部端口 -> 绑定地址，未指定的端口绑定 HostIP
+	Env       []string          // 环境变量列表 (例如 ["FLAG=ctf{...}"])
+	Labels    map[string]string // 附加标签，平台标签 ContainerLabelManaged 会自动加上
+	Network   string            // 加入的网络名称，为空时使用默认网络
+	Aliases   []string          // 容器在 Network 中的别名
+}
+
 // StartContainer 启动容器
-// image: 镜像名
-// ports: 容器内部端口 -> 协议 (例如 {"80": "tcp"})
-// env: 环境变量列表 (例如 ["FLAG=ctf{...}"])
 // 返回: containerID, hostMapping(容器端口->宿主机端口), error
-func StartContainer(image string, ports map[string]string, env []string) (string, map[string]string, error) {
+func StartContainer(opts ContainerOptions) (string, map[string]string, error) {
 	if err := EnsureDockerClient(); err != nil {
 		return "", nil, fmt.Errorf("docker client init failed: %v", err)
 	}
 
 	ctx := context.Background()
 
-	// 1. 尝试拉取镜像（如果本地不存在）
-	// 注意：生产环境建议预先拉取或配置私有仓库认证
-	reader, err := dockerClient.ImagePull(ctx, image, types.ImagePullOptions{})
-	if err == nil {
-		io.Copy(io.Discard, reader) // 读取输出以完成拉取
-		reader.Close()
-	} else {
-		// 如果拉取失败，尝试直接使用本地镜像
-		fmt.Printf("Image pull failed (using local if available): %v\n", err)
-	}
-
-	// 2. 配置端口映射
+	// 1. 配置端口映射
 	exposedPorts := nat.PortSet{}
 	portBindings := nat.PortMap{}
 
-	for port, proto := range ports {
+	hostIP := opts.HostIP
+	if hostIP == "" {
+		hostIP = "0.0.0.0"
+	}
+	for port, proto := range opts.Ports {
 		p := nat.Port(fmt.Sprintf("%s/%s", port, proto))
 		exposedPorts[p] = struct{}{}
-		// 绑定到所有接口的随机端口
+		hostPort := "" // 未指定时让 Docker 随机分配宿主机端口
+		if hp, ok := opts.HostPorts[port]; ok {
+			hostPort = strconv.Itoa(hp)
+		}
+		bindIP := hostIP
+		if ip, ok := opts.HostIPs[port]; ok {
+			bindIP = ip
+		}
 		portBindings[p] = []nat.PortBinding{
 			{
-				HostIP:   "0.0.0.0",
-				HostPort: "", // 让 Docker 随机分配宿主机端口
+				HostIP:   bindIP,
+				HostPort: hostPort,
 			},
 		}
 	}
 
-	// 3. 创建容器
-	resp, err := dockerClient.ContainerCreate(ctx, &container.Config{
-		Image:        image,
-		Env:          env,
+	// 2. 创建容器（镜像在题目创建时已拉取，本地不存在时才拉取一次）
+	containerLabels := map[string]string{ContainerLabelManaged: "true"}
+	for k, v := range opts.Labels {
+		containerLabels[k] = v
+	}
+	containerConfig := &container.Config{
+		Image:        opts.Image,
+		Env:          opts.Env,
 		ExposedPorts: exposedPorts,
-	}, &container.HostConfig{
+		Labels:       containerLabels,
+	}
+	hostConfig := &container.HostConfig{
 		PortBindings: portBindings,
 		// 可以在这里添加资源限制，例如 Memory: 512 * 1024 * 1024
 		AutoRemove: false, // 不自动删除，以便排查问题
-	}, nil, nil, "")
+	}
+	var networkingConfig *network.NetworkingConfig
+	if opts.Network != "" {
+		hostConfig.NetworkMode = container.NetworkMode(opts.Network)
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				opts.Network: {Aliases: opts.Aliases},
+			},
+		}
+	}
+	resp, err := dockerClient.ContainerCreate(ctx, containerConfig, hostConfig, networkingConfig, nil, "")
+	if err != nil && client.IsErrNotFound(err) {
+		if pullErr := PullImage(opts.Image); pullErr != nil {
+			return "", nil, pullErr
+		}
+		resp, err = dockerClient.ContainerCreate(ctx, containerConfig, hostConfig, networkingConfig, nil, "")
+	}
 	if err != nil {
 		return "", nil, err
 	}
 
-	// 4. 启动容器
+	// 3. 启动容器
 	if err := dockerClient.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
 		// 启动失败尝试清理
 		_ = dockerClient.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
 		return "", nil, err
 	}
 
-	// 5. 获取分配的宿主机端口
+	// 4. 获取分配的宿主机端口
 	inspect, err := dockerClient.ContainerInspect(ctx, resp.ID)
 	if err != nil {
 		return resp.ID, nil, err
@@ -108,6 +161,49 @@ func StartContainer(image string, ports map[string]string, env []string) (string
 	return resp.ID, hostMapping, nil
 }
 
+// PullImage 拉取镜像
+// 注意：私有仓库需预先在 Docker 守护进程中配置认证
+func PullImage(image string) error {
+	if err := EnsureDockerClient(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	reader, err := dockerClient.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err = io.Copy(io.Discard, reader) // 读取输出以完成拉取
+	return err
+}
+
+// WriteContainerFile 向运行中的容器写入文件（用于分配预热容器时注入 Flag）
+func WriteContainerFile(containerID, filePath string, content []byte) error {
+	if err := EnsureDockerClient(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    path.Base(filePath),
+		Mode:    0444,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(content); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	return dockerClient.CopyToContainer(ctx, containerID, path.Dir(filePath), &buf, container.CopyToContainerOptions{})
+}
+
 // StopContainer 停止容器
 func StopContainer(containerID string) error {
 	if err := EnsureDockerClient(); err != nil {
@@ -127,6 +223,53 @@ func RemoveContainer(containerID string) error {
 	return dockerClient.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true})
 }
 
+// CreateNetwork 创建带平台标签的 bridge 网络，用于隔离多容器题目的服务
+// 返回网络 ID
+func CreateNetwork(name string, labels map[string]string) (string, error) {
+	if err := EnsureDockerClient(); err != nil {
+		return "", err
+	}
+	networkLabels := map[string]string{ContainerLabelManaged: "true"}
+	for k, v := range labels {
+		networkLabels[k] = v
+	}
+	ctx := context.Background()
+	resp, err := dockerClient.NetworkCreate(ctx, name, network.CreateOptions{
+		Driver: "bridge",
+		Labels: networkLabels,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// RemoveNetwork 删除网络，网络中的容器需先删除
+func RemoveNetwork(networkID string) error {
+	if err := EnsureDockerClient(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	return dockerClient.NetworkRemove(ctx, networkID)
+}
+
+// PrunePlatformNetworks 清理创建时间早于 olderThan 且没有容器的平台网络
+// 返回清理的网络数量
+func PrunePlatformNetworks(olderThan time.Duration) (int, error) {
+	if err := EnsureDockerClient(); err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+	report, err := dockerClient.NetworksPrune(ctx, filters.NewArgs(
+		filters.Arg("label", ContainerLabelManaged+"=true"),
+		filters.Arg("until", olderThan.String()),
+	))
+	if err != nil {
+		return 0, err
+	}
+	return len(report.NetworksDeleted), nil
+}
+
 // GetContainerStatus 获取容器运行状态
 func GetDockerContainerStatus(containerID string) (string, error) {
 	if err := EnsureDockerClient(); err != nil {
@@ -140,6 +283,37 @@ func GetDockerContainerStatus(containerID string) (string, error) {
 	return inspect.State.Status, nil // running, exited, dead, etc.
 }
 
+// ListPlatformContainers 列出所有带平台标签的容器（包括已退出的）
+func ListPlatformContainers() ([]DockerContainerInfo, error) {
+	if err := EnsureDockerClient(); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	list, err := dockerClient.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", ContainerLabelManaged+"=true")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]DockerContainerInfo, 0, len(list))
+	for _, c := range list {
+		result = append(result, DockerContainerInfo{
+			ID:      c.ID,
+			State:   string(c.State),
+			Labels:  c.Labels,
+			Created: time.Unix(c.Created, 0),
+		})
+	}
+	return result, nil
+}
+
+// IsContainerNotFound 判断 Docker 返回的错误是否为容器不存在
+func IsContainerNotFound(err error) bool {
+	return client.IsErrNotFound(err)
+}
+
 // GenerateDynamicFlag 生成动态 Flag
 func GenerateDynamicFlag(teamID, challengeID int64) string {
 	randPart := fmt.Sprintf("%08x", rand.Int63())
@@ -1,32 +1,257 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"isctf/config"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWT 密钥，实际项目中应该放在配置文件中
-var jwtSecret = []byte("isctf-secret-key-2024")
+// jwtKey 单个签名/验签密钥
+type jwtKey struct {
+	kid    string
+	method jwt.SigningMethod
+	sign   interface{} // 签名密钥，仅当前密钥持有
+	verify interface{} // 验签密钥
+}
+
+// jwtKeyring 密钥环：当前密钥负责签名，历史密钥仅用于验签，便于轮换
+type jwtKeyring struct {
+	current *jwtKey
+	keys    map[string]*jwtKey
+}
+
+var keyring *jwtKeyring
 
 // Claims JWT 声明
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"` // admin, user, school_admin
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`          // admin, user, school_admin
+	SessionID int64  `json:"sid"`           // 登录会话ID，会话吊销后 Token 立即失效
+	TwoFactor bool   `json:"tfa,omitempty"` // 会话是否通过两步验证
+	jwt.RegisteredClaims
+}
+
+// twoFactorAudience 两步验证临时 Token 的受众，与 Access Token 区分
+const twoFactorAudience = "isctf-2fa"
+
+// TwoFactorTokenTTL 两步验证临时 Token 有效期
+const TwoFactorTokenTTL = 5 * time.Minute
+
+// oidcStateAudience OIDC 认证状态 Token 的受众
+const oidcStateAudience = "isctf-oidc"
+
+// OIDCState OIDC 认证过程状态，签名后交由前端保存，回调时校验
+type OIDCState struct {
+	Provider   string `json:"provider"`
+	State      string `json:"state"`
+	Nonce      string `json:"nonce"`
+	Verifier   string `json:"verifier"`           // PKCE code_verifier
+	LinkUserID int64  `json:"link_uid,omitempty"` // 非 0 表示为已登录用户绑定外部身份
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成 JWT Token
-func GenerateToken(userID int64, username, role string) (string, error) {
+// JWK JSON Web Key（仅公钥）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"` // OKP
+	X   string `json:"x,omitempty"`   // OKP
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+}
+
+// JWKSet JWKS 响应
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// InitJWTKeys 根据配置加载 JWT 密钥环，需在 config.InitConfig 之后调用
+func InitJWTKeys() {
+	ring, err := loadKeyring(&config.AppConfig.JWT, config.AppConfig.Server.Mode)
+	if err != nil {
+		log.Fatal("JWT 密钥加载失败:", err)
+	}
+	keyring = ring
+	fmt.Printf("JWT 密钥加载成功（算法: %s, kid: %s, 验签密钥数: %d）\n",
+		ring.current.method.Alg(), ring.current.kid, len(ring.keys))
+}
+
+// loadKeyring 解析当前密钥与历史验签密钥
+func loadKeyring(cfg *config.JWTConfig, mode string) (*jwtKeyring, error) {
+	if cfg.KeyID == "" {
+		return nil, errors.New("JWT_KEY_ID 不能为空")
+	}
+
+	current := &jwtKey{kid: cfg.KeyID}
+	switch cfg.Algorithm {
+	case "HS256":
+		current.method = jwt.SigningMethodHS256
+		secret := []byte(cfg.Secret)
+		if len(secret) == 0 {
+			// 未配置密钥时仅允许在开发环境使用随机密钥，重启后已签发 Token 全部失效
+			if mode == "release" {
+				return nil, errors.New("生产环境必须配置 JWT_SECRET")
+			}
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, err
+			}
+			fmt.Println("警告: 未配置 JWT_SECRET，已生成临时随机密钥，服务重启后需重新登录")
+		} else if len(secret) < 32 {
+			return nil, errors.New("JWT_SECRET 长度不能少于32字节")
+		}
+		current.sign, current.verify = secret, secret
+	case "EdDSA", "RS256":
+		signer, err := loadPrivateKey(cfg.PrivateKeyFile, cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		current.method = jwt.GetSigningMethod(cfg.Algorithm)
+		current.sign, current.verify = signer, signer.Public()
+	default:
+		return nil, fmt.Errorf("不支持的 JWT 算法: %s", cfg.Algorithm)
+	}
+
+	ring := &jwtKeyring{
+		current: current,
+		keys:    map[string]*jwtKey{current.kid: current},
+	}
+
+	// 历史密钥：与当前密钥使用同一算法，仅用于验签轮换期内尚未过期的 Token
+	for _, item := range strings.Split(cfg.VerifyKeys, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kid, value, ok := strings.Cut(item, "=")
+		kid, value = strings.TrimSpace(kid), strings.TrimSpace(value)
+		if !ok || kid == "" || value == "" {
+			return nil, fmt.Errorf("JWT_VERIFY_KEYS 格式错误: %s", item)
+		}
+		if _, exists := ring.keys[kid]; exists {
+			return nil, fmt.Errorf("JWT 密钥 kid 重复: %s", kid)
+		}
+
+		key := &jwtKey{kid: kid, method: current.method}
+		if cfg.Algorithm == "HS256" {
+			key.verify = []byte(value)
+		} else {
+			pub, err := loadPublicKey(value, cfg.Algorithm)
+			if err != nil {
+				return nil, err
+			}
+			key.verify = pub
+		}
+		ring.keys[kid] = key
+	}
+
+	return ring, nil
+}
+
+// loadPrivateKey 从 PEM 文件加载私钥（PKCS#8，RSA 额外支持 PKCS#1）
+func loadPrivateKey(path, alg string) (crypto.Signer, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%s 模式必须配置 JWT_PRIVATE_KEY_FILE", alg)
+	}
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	if alg == "RS256" && block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %w", err)
+	}
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		if alg == "EdDSA" {
+			return k, nil
+		}
+	case *rsa.PrivateKey:
+		if alg == "RS256" {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("私钥类型与算法 %s 不匹配", alg)
+}
+
+// loadPublicKey 从 PEM 文件加载公钥（PKIX）
+func loadPublicKey(path, alg string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %w", err)
+	}
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		if alg == "EdDSA" {
+			return k, nil
+		}
+	case *rsa.PublicKey:
+		if alg == "RS256" {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("公钥 %s 类型与算法 %s 不匹配", path, alg)
+}
+
+// readPEM 读取 PEM 文件中的第一个块
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("密钥文件 %s 不是有效的 PEM 格式", path)
+	}
+	return block, nil
+}
+
+// AccessTokenTTL 获取 Access Token 有效期
+func AccessTokenTTL() time.Duration {
+	return time.Duration(config.AppConfig.JWT.AccessTokenTTL) * time.Minute
+}
+
+// GenerateToken 生成短期 Access Token，返回 Token 及过期时间
+func GenerateToken(userID int64, username, role string, sessionID int64, twoFactor bool) (string, time.Time, error) {
 	nowTime := time.Now()
-	expireTime := nowTime.Add(24 * time.Hour) // 24小时过期
+	expireTime := nowTime.Add(AccessTokenTTL())
 
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
+		TwoFactor: twoFactor,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 			IssuedAt:  jwt.NewNumericDate(nowTime),
@@ -35,17 +260,86 @@ func GenerateToken(userID int64, username, role string) (string, error) {
 		},
 	}
 
-	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err := tokenClaims.SignedString(jwtSecret)
+	token, err := signClaims(claims)
+	return token, expireTime, err
+}
+
+// GenerateTwoFactorToken 密码验证通过后签发两步验证临时 Token，仅可用于提交验证码
+func GenerateTwoFactorToken(userID int64) (string, error) {
+	nowTime := time.Now()
+	claims := Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(nowTime.Add(TwoFactorTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(nowTime),
+			NotBefore: jwt.NewNumericDate(nowTime),
+			Issuer:    "isctf",
+			Audience:  jwt.ClaimStrings{twoFactorAudience},
+		},
+	}
+	return signClaims(claims)
+}
+
+// GenerateOIDCStateToken 签发 OIDC 认证状态 Token（10分钟有效）
+func GenerateOIDCStateToken(state OIDCState) (string, error) {
+	nowTime := time.Now()
+	state.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(nowTime.Add(10 * time.Minute)),
+		IssuedAt:  jwt.NewNumericDate(nowTime),
+		NotBefore: jwt.NewNumericDate(nowTime),
+		Issuer:    "isctf",
+		Audience:  jwt.ClaimStrings{oidcStateAudience},
+	}
+	return signClaims(state)
+}
+
+// ParseOIDCStateToken 解析 OIDC 认证状态 Token
+func ParseOIDCStateToken(token string) (*OIDCState, error) {
+	tokenClaims, err := jwt.ParseWithClaims(token, &OIDCState{}, lookupKey,
+		jwt.WithIssuer("isctf"), jwt.WithAudience(oidcStateAudience))
+	if err != nil {
+		return nil, err
+	}
+	if state, ok := tokenClaims.Claims.(*OIDCState); ok && tokenClaims.Valid {
+		return state, nil
+	}
+	return nil, errors.New("invalid token")
+}
 
-	return token, err
+// signClaims 使用当前密钥签名
+func signClaims(claims jwt.Claims) (string, error) {
+	current := keyring.current
+	tokenClaims := jwt.NewWithClaims(current.method, claims)
+	tokenClaims.Header["kid"] = current.kid
+	return tokenClaims.SignedString(current.sign)
 }
 
-// ParseToken 解析 JWT Token
+// ParseToken 解析 Access Token，按 kid 选择验签密钥
 func ParseToken(token string) (*Claims, error) {
-	tokenClaims, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	claims, err := parseClaims(token)
+	if err != nil {
+		return nil, err
+	}
+	// 两步验证临时 Token 不能当作 Access Token 使用
+	if len(claims.Audience) > 0 {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// ParseTwoFactorToken 解析两步验证临时 Token，返回用户ID
+func ParseTwoFactorToken(token string) (int64, error) {
+	claims, err := parseClaims(token, jwt.WithAudience(twoFactorAudience))
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// parseClaims 验签并解析声明
+func parseClaims(token string, opts ...jwt.ParserOption) (*Claims, error) {
+	opts = append(opts, jwt.WithIssuer("isctf"))
+	tokenClaims, err := jwt.ParseWithClaims(token, &Claims{}, lookupKey, opts...)
 
 	if err != nil {
 		return nil, err
@@ -58,17 +352,39 @@ func ParseToken(token string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken 刷新 Token
-func RefreshToken(token string) (string, error) {
-	claims, err := ParseToken(token)
-	if err != nil {
-		return "", err
+// lookupKey 按 kid 选择验签密钥
+func lookupKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keyring.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown kid")
 	}
-
-	// 如果 Token 还有超过 2 小时才过期，则不刷新
-	if time.Until(claims.ExpiresAt.Time) > 2*time.Hour {
-		return token, nil
+	// 防止算法混淆：Token 声明的算法必须与密钥算法一致
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
 	}
+	return key.verify, nil
+}
 
-	return GenerateToken(claims.UserID, claims.Username, claims.Role)
+// GetJWKS 获取公钥集合，供其他服务验签；HS256 模式下密钥不可公开，返回空集合
+func GetJWKS() *JWKSet {
+	set := &JWKSet{Keys: []JWK{}}
+	for _, key := range keyring.keys {
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.verify.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
 }
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"isctf/config"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+)
+
+// SendMail 发送纯文本邮件
+// 未配置 SMTP_HOST 时仅在控制台记录收件人与主题；正文含令牌，需显式开启 SMTP_LOG_BODY 才会打印
+func SendMail(to, subject, body string) error {
+	cfg := config.AppConfig.Mail
+	if cfg.Host == "" {
+		fmt.Printf("📧 未配置 SMTP，跳过发送邮件到 %s: [%s]\n", to, subject)
+		if cfg.LogBody {
+			fmt.Println(body)
+		}
+		return nil
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return fmt.Errorf("发件人地址无效: %v", err)
+	}
+
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + to,
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	msg := []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body)
+
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+	// 未配置用户名时不进行认证
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	// 465 端口使用隐式 TLS，其余端口使用 STARTTLS
+	if cfg.Port != "465" {
+		return smtp.SendMail(addr, auth, from.Address, []string{to}, msg)
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: cfg.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
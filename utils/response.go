@@ -27,6 +27,8 @@ const (
 	PASSWORD_ERROR           = 1004 // 密码错误
 	USER_NOT_EXIST           = 1005 // 用户不存在
 	USER_ALREADY_EXIST       = 1006 // 用户已存在
+	TWO_FACTOR_REQUIRED      = 1007 // 需要两步验证
+	TWO_FACTOR_INVALID       = 1008 // 两步验证码错误
 
 	// 学校相关错误码
 	SCHOOL_NOT_EXIST    = 2001 // 学校不存在
@@ -42,6 +44,24 @@ const (
 	TEAM_PASSWORD_ERROR     = 3006 // 团队密码错误
 	TEAM_NOT_CAPTAIN        = 3007 // 非队长无法执行此操作
 	TEAM_CAPTAIN_CANNOT_LEAVE = 3008 // 队长无法离开团队
+
+	// 公告相关错误码
+	NOTICE_NOT_EXIST = 4001 // 公告不存在
+
+	// 提示相关错误码
+	HINT_NOT_EXIST        = 5001 // 提示不存在
+	HINT_ALREADY_UNLOCKED = 5002 // 已解锁该提示
+	HINT_SCORE_NOT_ENOUGH = 5003 // 团队分数不足
+
+	// 题目相关错误码
+	CHALLENGE_LOCKED            = 6001 // 题目尚未解锁
+	CHALLENGE_PREREQUISITE_LOOP = 6002 // 题目依赖存在循环
+
+	// 容器相关错误码
+	CONTAINER_PORT_EXHAUSTED = 7001 // 端口池已耗尽
+	CONTAINER_TEAM_LIMIT     = 7002 // 团队容器数量已达上限
+	CONTAINER_CAPACITY_FULL  = 7003 // 容器资源已满
+	CONTAINER_GATEWAY_LIMIT  = 7004 // 团队 TCP 中转连接数已达上限
 )
 
 // 错误信息映射
@@ -59,6 +79,8 @@ var codeMsg = map[int]string{
 	USER_NOT_EXIST:            "用户不存在",
 	USER_ALREADY_EXIST:        "用户已存在",
 	PASSWORD_ERROR:            "密码错误",
+	TWO_FACTOR_REQUIRED:       "需要两步验证",
+	TWO_FACTOR_INVALID:        "两步验证码错误",
 	SCHOOL_NOT_EXIST:          "学校不存在",
 	SCHOOL_ALREADY_EXIST:      "学校已存在",
 	SCHOOL_SUSPENDED:          "学校已被封禁",
@@ -70,6 +92,16 @@ var codeMsg = map[int]string{
 	TEAM_PASSWORD_ERROR:       "团队密码错误",
 	TEAM_NOT_CAPTAIN:          "非队长无法执行此操作",
 	TEAM_CAPTAIN_CANNOT_LEAVE: "队长无法离开团队",
+	NOTICE_NOT_EXIST:          "公告不存在",
+	HINT_NOT_EXIST:            "提示不存在",
+	HINT_ALREADY_UNLOCKED:     "已解锁该提示",
+	HINT_SCORE_NOT_ENOUGH:     "团队分数不足，无法解锁提示",
+	CHALLENGE_LOCKED:          "题目尚未解锁",
+	CHALLENGE_PREREQUISITE_LOOP: "题目依赖存在循环",
+	CONTAINER_PORT_EXHAUSTED:  "容器端口已耗尽，请稍后再试",
+	CONTAINER_TEAM_LIMIT:      "团队运行中的容器数量已达上限，请先停止其他容器",
+	CONTAINER_CAPACITY_FULL:   "容器资源已满，请稍后再试或加入排队",
+	CONTAINER_GATEWAY_LIMIT:   "团队 TCP 中转连接数已达上限，请先关闭其他连接",
 }
 
 // GetMsg 获取状态码对应的信息